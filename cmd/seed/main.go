@@ -9,6 +9,7 @@ import (
 	"github.com/igorgrichanov/toDoList/internal/config"
 	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
 	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository/statuses"
 	"github.com/igorgrichanov/toDoList/internal/repository/tasks"
 	"github.com/igorgrichanov/toDoList/internal/service/tasksService"
 	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
@@ -41,9 +42,10 @@ func main() {
 
 	// repository
 	repo := tasks.NewTasksRepository(log, db)
+	statusesRepo := statuses.NewStatusesRepository(log, db)
 
 	// service
-	ts := tasksService.NewUseCase(log, repo)
+	ts := tasksService.NewUseCase(log, repo, statusesRepo, &conf.Tasks)
 
 	// check if database is empty
 	var countTasks int
@@ -58,13 +60,17 @@ func main() {
 		return
 	}
 
-	statuses := []string{"new", "in_progress", "done"}
+	board, err := statusesRepo.List(context.Background())
+	if err != nil {
+		log.Error("failed to get statuses", sl.Err(err))
+		os.Exit(1)
+	}
 	inserted := 0
 	for i := 0; i < *count; i++ {
 		task := &models.Task{
 			Title:       gofakeit.Name(),
 			Description: gofakeit.Sentence(5),
-			Status:      statuses[rand.Intn(len(statuses))],
+			Status:      board[rand.Intn(len(board))].Name,
 		}
 		ctx := context.WithValue(context.Background(), request_id.RequestIDKey, uuid.New().String())
 		_, err = ts.CreateTask(ctx, task)
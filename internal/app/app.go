@@ -7,8 +7,25 @@ import (
 	"github.com/igorgrichanov/toDoList/internal/config"
 	"github.com/igorgrichanov/toDoList/internal/controller"
 	httpRouter "github.com/igorgrichanov/toDoList/internal/controller/http"
+	commentsController "github.com/igorgrichanov/toDoList/internal/controller/http/v1/comments"
+	projectsController "github.com/igorgrichanov/toDoList/internal/controller/http/v1/projects"
+	statusesController "github.com/igorgrichanov/toDoList/internal/controller/http/v1/statuses"
+	tagsController "github.com/igorgrichanov/toDoList/internal/controller/http/v1/tags"
 	tasksController "github.com/igorgrichanov/toDoList/internal/controller/http/v1/tasks"
+	"github.com/igorgrichanov/toDoList/internal/notifier"
+	"github.com/igorgrichanov/toDoList/internal/repository/comments"
+	"github.com/igorgrichanov/toDoList/internal/repository/idempotency"
+	"github.com/igorgrichanov/toDoList/internal/repository/projects"
+	"github.com/igorgrichanov/toDoList/internal/repository/reminders"
+	"github.com/igorgrichanov/toDoList/internal/repository/statuses"
+	"github.com/igorgrichanov/toDoList/internal/repository/tags"
 	"github.com/igorgrichanov/toDoList/internal/repository/tasks"
+	"github.com/igorgrichanov/toDoList/internal/scheduler"
+	"github.com/igorgrichanov/toDoList/internal/service/commentsService"
+	"github.com/igorgrichanov/toDoList/internal/service/projectsService"
+	"github.com/igorgrichanov/toDoList/internal/service/remindersService"
+	"github.com/igorgrichanov/toDoList/internal/service/statusesService"
+	"github.com/igorgrichanov/toDoList/internal/service/tagsService"
 	"github.com/igorgrichanov/toDoList/internal/service/tasksService"
 	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
 	"github.com/igorgrichanov/toDoList/pkg/postgres"
@@ -29,17 +46,49 @@ func Run(log *slog.Logger, conf *config.Config) {
 
 	// infrastructure
 	repo := tasks.NewTasksRepository(log, db)
+	tagsRepo := tags.NewTagsRepository(log, db)
+	projectsRepo := projects.NewProjectsRepository(log, db, repo)
+	statusesRepo := statuses.NewStatusesRepository(log, db)
+	remindersRepo := reminders.NewRemindersRepository(log, db)
+	commentsRepo := comments.NewCommentsRepository(log, db)
+	keys := idempotency.NewKeysRepository(log, db)
+	if err = repo.UseSearchLanguage(context.Background(), conf.Tasks.SearchLanguage); err != nil {
+		log.Error("Error setting up text search", sl.Err(err))
+		os.Exit(1)
+	}
 	validate := validator.New()
 
 	// service
-	uc := tasksService.NewUseCase(log, repo)
+	uc := tasksService.NewUseCase(log, repo, statusesRepo, &conf.Tasks)
+	if err = uc.CheckWorkflow(context.Background()); err != nil {
+		log.Error("Error checking TASKS_WORKFLOW", sl.Err(err))
+		os.Exit(1)
+	}
+	tagsUC := tagsService.NewUseCase(log, tagsRepo)
+	projectsUC := projectsService.NewUseCase(log, projectsRepo, &conf.Projects)
+	statusesUC := statusesService.NewUseCase(log, statusesRepo)
+	commentsUC := commentsService.NewUseCase(log, commentsRepo)
+	remindersUC := remindersService.NewUseCase(log, remindersRepo, newNotifier(log, &conf.Reminders), &conf.Reminders)
 
 	// controller
+	if err = statusesController.RegisterValidation(validate, statusesUC); err != nil {
+		log.Error("Error registering status validation", sl.Err(err))
+		os.Exit(1)
+	}
 	tasksCtrl := tasksController.NewTaskController(log, uc, validate)
-	ctrl := controller.New(tasksCtrl)
+	tagsCtrl := tagsController.NewTagController(log, tagsUC, validate)
+	projectsCtrl := projectsController.NewProjectController(log, projectsUC, validate)
+	statusesCtrl := statusesController.NewStatusController(log, statusesUC, validate)
+	commentsCtrl := commentsController.NewCommentController(log, commentsUC, validate)
+	ctrl := controller.New(tasksCtrl, tagsCtrl, projectsCtrl, statusesCtrl, commentsCtrl)
 
 	// router
-	app := httpRouter.NewRouter(log, &conf.Server, ctrl)
+	app := httpRouter.NewRouter(log, &conf.Server, ctrl, keys)
+
+	// background jobs
+	sched := scheduler.New(log)
+	sched.Add("reminders", conf.Reminders.Interval, remindersUC.SendDue)
+	sched.Start()
 
 	addr := conf.Server.Host + ":" + conf.Server.Port
 	done := make(chan os.Signal, 1)
@@ -63,4 +112,21 @@ func Run(log *slog.Logger, conf *config.Config) {
 	} else {
 		log.Info("server shutdown complete")
 	}
+	if err := sched.Stop(ctx); err != nil {
+		log.Error("error while stopping scheduler", sl.Err(err))
+	} else {
+		log.Info("scheduler stopped")
+	}
+}
+
+// newNotifier returns the notifier chosen in the config.
+func newNotifier(log *slog.Logger, conf *config.Reminders) remindersService.Notifier {
+	switch conf.Notifier {
+	case "webhook":
+		return notifier.NewWebhook(conf.WebhookURL, conf.WebhookTimeout)
+	case "smtp":
+		return notifier.NewSMTP(&conf.SMTP)
+	default:
+		return notifier.NewLog(log)
+	}
 }
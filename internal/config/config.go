@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	DB     DB     `yaml:"db"`
-	Server Server `yaml:"server"`
+	DB        DB        `yaml:"db"`
+	Server    Server    `yaml:"server"`
+	Tasks     Tasks     `yaml:"tasks"`
+	Projects  Projects  `yaml:"projects"`
+	Reminders Reminders `yaml:"reminders"`
 }
 
 type DB struct {
@@ -32,6 +36,61 @@ type Server struct {
 	ReadTimeout     time.Duration `yaml:"read_timeout"`
 	WriteTimeout    time.Duration `yaml:"write_timeout"`
 	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	// IdempotencyKeyTTL is how long responses to requests with an Idempotency-Key are kept.
+	IdempotencyKeyTTL time.Duration `yaml:"idempotency_key_ttl"`
+	// IdempotencyKeyLease is how long a key is held by a request being processed, after it expires
+	// a retry takes the key over, e.g. when the server has stopped before storing the response.
+	IdempotencyKeyLease time.Duration `yaml:"idempotency_key_lease"`
+}
+
+type Tasks struct {
+	// TrashRetention is how long deleted tasks are kept in the trash before they can be purged.
+	TrashRetention time.Duration `yaml:"trash_retention"`
+	// RequireSubtasksDone forbids moving a task to a terminal status while some of its subtasks are not finished.
+	RequireSubtasksDone bool `yaml:"require_subtasks_done"`
+	// Workflow lists the allowed status changes, any change is allowed if it is empty.
+	// Changes to and from the statuses it doesn't mention are allowed as well.
+	Workflow []Transition `yaml:"workflow"`
+	// SearchLanguage is the PostgreSQL text search configuration used to index and search tasks, e.g. english.
+	// Tasks are reindexed on start when it changes.
+	SearchLanguage string `yaml:"search_language"`
+}
+
+type Transition struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// RequiresReason makes the client explain the change, e.g. why a done task is reopened.
+	RequiresReason bool `yaml:"requires_reason"`
+}
+
+type Projects struct {
+	// DeleteMode is what happens to tasks of a deleted project unless the request overrides it:
+	// reject, cascade or inbox.
+	DeleteMode string `yaml:"delete_mode"`
+}
+
+type Reminders struct {
+	// Interval is how often due reminders are looked for.
+	Interval time.Duration `yaml:"interval"`
+	// BatchSize is how many reminders are claimed at once.
+	BatchSize int `yaml:"batch_size"`
+	// Lease is how long claimed reminders are kept from other replicas while being sent,
+	// the ones that failed to send are retried after it.
+	Lease time.Duration `yaml:"lease"`
+	// Notifier delivers reminders: log, webhook or smtp.
+	Notifier       string        `yaml:"notifier"`
+	WebhookURL     string        `yaml:"webhook_url"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout"`
+	SMTP           SMTP          `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string   `yaml:"host"`
+	Port     string   `yaml:"port"`
+	User     string   `yaml:"user"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
 }
 
 func New() (*Config, error) {
@@ -90,10 +149,132 @@ func New() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse SERVER_IDLE_TIMEOUT: %w", err)
 	}
+	serverIdempotencyKeyTTL, err := time.ParseDuration(os.Getenv("SERVER_IDEMPOTENCY_KEY_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SERVER_IDEMPOTENCY_KEY_TTL: %w", err)
+	}
+	serverIdempotencyKeyLease, err := time.ParseDuration(os.Getenv("SERVER_IDEMPOTENCY_KEY_LEASE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SERVER_IDEMPOTENCY_KEY_LEASE: %w", err)
+	}
+	if serverIdempotencyKeyLease <= 0 {
+		return nil, fmt.Errorf("invalid SERVER_IDEMPOTENCY_KEY_LEASE '%s': must be positive", serverIdempotencyKeyLease)
+	}
 	conf.Server.ShutdownTimeout = serverShutdownTimeout
 	conf.Server.ReadTimeout = serverReadTimeout
 	conf.Server.WriteTimeout = serverWriteTimeout
 	conf.Server.IdleTimeout = serverIdleTimeout
+	conf.Server.IdempotencyKeyTTL = serverIdempotencyKeyTTL
+	conf.Server.IdempotencyKeyLease = serverIdempotencyKeyLease
+
+	tasksTrashRetention, err := time.ParseDuration(os.Getenv("TASKS_TRASH_RETENTION"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TASKS_TRASH_RETENTION: %w", err)
+	}
+	tasksRequireSubtasksDone, err := strconv.ParseBool(os.Getenv("TASKS_REQUIRE_SUBTASKS_DONE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TASKS_REQUIRE_SUBTASKS_DONE: %w", err)
+	}
+	conf.Tasks.TrashRetention = tasksTrashRetention
+	conf.Tasks.RequireSubtasksDone = tasksRequireSubtasksDone
+	tasksWorkflow, err := parseWorkflow(os.Getenv("TASKS_WORKFLOW"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TASKS_WORKFLOW: %w", err)
+	}
+	conf.Tasks.Workflow = tasksWorkflow
+	conf.Tasks.SearchLanguage = strings.TrimSpace(os.Getenv("TASKS_SEARCH_LANGUAGE"))
+	if conf.Tasks.SearchLanguage == "" || strings.Trim(conf.Tasks.SearchLanguage, "abcdefghijklmnopqrstuvwxyz_") != "" {
+		return nil, fmt.Errorf("invalid TASKS_SEARCH_LANGUAGE '%s': must be a name of a text search configuration", conf.Tasks.SearchLanguage)
+	}
+
+	conf.Projects.DeleteMode = os.Getenv("PROJECTS_DELETE_MODE")
+	switch conf.Projects.DeleteMode {
+	case "reject", "cascade", "inbox":
+	default:
+		return nil, fmt.Errorf("invalid PROJECTS_DELETE_MODE '%s': must be one of reject, cascade, inbox", conf.Projects.DeleteMode)
+	}
+
+	remindersInterval, err := time.ParseDuration(os.Getenv("REMINDERS_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse REMINDERS_INTERVAL: %w", err)
+	}
+	if remindersInterval <= 0 {
+		return nil, fmt.Errorf("invalid REMINDERS_INTERVAL '%s': must be positive", remindersInterval)
+	}
+	remindersBatchSize, err := strconv.Atoi(os.Getenv("REMINDERS_BATCH_SIZE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse REMINDERS_BATCH_SIZE: %w", err)
+	}
+	if remindersBatchSize < 1 {
+		return nil, fmt.Errorf("invalid REMINDERS_BATCH_SIZE %d: must be positive", remindersBatchSize)
+	}
+	remindersLease, err := time.ParseDuration(os.Getenv("REMINDERS_LEASE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse REMINDERS_LEASE: %w", err)
+	}
+	if remindersLease <= 0 {
+		return nil, fmt.Errorf("invalid REMINDERS_LEASE '%s': must be positive", remindersLease)
+	}
+	conf.Reminders.Interval = remindersInterval
+	conf.Reminders.BatchSize = remindersBatchSize
+	conf.Reminders.Lease = remindersLease
+	conf.Reminders.Notifier = os.Getenv("REMINDERS_NOTIFIER")
+	switch conf.Reminders.Notifier {
+	case "log":
+	case "webhook":
+		conf.Reminders.WebhookURL = os.Getenv("REMINDERS_WEBHOOK_URL")
+		if conf.Reminders.WebhookURL == "" {
+			return nil, fmt.Errorf("REMINDERS_WEBHOOK_URL is required for the webhook notifier")
+		}
+		webhookTimeout, err := time.ParseDuration(os.Getenv("REMINDERS_WEBHOOK_TIMEOUT"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse REMINDERS_WEBHOOK_TIMEOUT: %w", err)
+		}
+		conf.Reminders.WebhookTimeout = webhookTimeout
+	case "smtp":
+		conf.Reminders.SMTP = SMTP{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			User:     os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		}
+		for _, to := range strings.Split(os.Getenv("SMTP_TO"), ",") {
+			if to = strings.TrimSpace(to); to != "" {
+				conf.Reminders.SMTP.To = append(conf.Reminders.SMTP.To, to)
+			}
+		}
+		if conf.Reminders.SMTP.Host == "" || conf.Reminders.SMTP.Port == "" || conf.Reminders.SMTP.From == "" || len(conf.Reminders.SMTP.To) == 0 {
+			return nil, fmt.Errorf("SMTP_HOST, SMTP_PORT, SMTP_FROM and SMTP_TO are required for the smtp notifier")
+		}
+	default:
+		return nil, fmt.Errorf("invalid REMINDERS_NOTIFIER '%s': must be one of log, webhook, smtp", conf.Reminders.Notifier)
+	}
 
 	return conf, nil
 }
+
+// parseWorkflow parses comma-separated transitions like "new:done,done:new:reason",
+// the reason suffix means that the transition requires a reason.
+func parseWorkflow(s string) ([]Transition, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	items := strings.Split(s, ",")
+	transitions := make([]Transition, 0, len(items))
+	for _, item := range items {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid transition '%s'", item)
+		}
+		t := Transition{From: parts[0], To: parts[1]}
+		if len(parts) == 3 {
+			if parts[2] != "reason" {
+				return nil, fmt.Errorf("invalid transition '%s'", item)
+			}
+			t.RequiresReason = true
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, nil
+}
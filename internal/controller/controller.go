@@ -1,13 +1,28 @@
 package controller
 
-import "github.com/igorgrichanov/toDoList/internal/controller/http/v1/tasks"
+import (
+	"github.com/igorgrichanov/toDoList/internal/controller/http/v1/comments"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/v1/projects"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/v1/statuses"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/v1/tags"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/v1/tasks"
+)
 
 type Controllers struct {
-	Tasks tasks.Tasker
+	Tasks    tasks.Tasker
+	Tags     tags.Tagger
+	Projects projects.Projector
+	Statuses statuses.Statuser
+	Comments comments.Commenter
 }
 
-func New(taskController tasks.Tasker) *Controllers {
+func New(taskController tasks.Tasker, tagController tags.Tagger, projectController projects.Projector,
+	statusController statuses.Statuser, commentController comments.Commenter) *Controllers {
 	return &Controllers{
-		Tasks: taskController,
+		Tasks:    taskController,
+		Tags:     tagController,
+		Projects: projectController,
+		Statuses: statusController,
+		Comments: commentController,
 	}
 }
@@ -0,0 +1,36 @@
+package actor
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// Key to use when setting the actor. Non-exported type is used to avoid collisions
+type ctxKeyActor int
+
+// ActorKey is the key that holds the name of the client making the request in a request context.
+const ActorKey ctxKeyActor = 0
+
+// ActorHeader is the name of the HTTP Header which tells who makes the request.
+var ActorHeader = "X-Actor"
+
+// Anonymous is the actor of requests without the header.
+const Anonymous = "anonymous"
+
+const maxActorLength = 100
+
+// NewActorMiddleware must be used after the request id middleware, which replaces the user context.
+func NewActorMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := strings.TrimSpace(c.Get(ActorHeader))
+		if actor == "" {
+			actor = Anonymous
+		}
+		if runes := []rune(actor); len(runes) > maxActorLength {
+			actor = string(runes[:maxActorLength])
+		}
+		c.SetUserContext(context.WithValue(c.UserContext(), ActorKey, actor))
+		return c.Next()
+	}
+}
@@ -0,0 +1,153 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// KeyHeader is the name of the HTTP Header which contains the idempotency key.
+var KeyHeader = "Idempotency-Key"
+
+// ReplayedHeader is set on responses restored from a previous request with the same key.
+var ReplayedHeader = "Idempotent-Replayed"
+
+const maxKeyLength = 255
+
+// storedHeaders are the response headers sent again along with the stored body.
+var storedHeaders = []string{fiber.HeaderContentType, fiber.HeaderETag}
+
+type Store interface {
+	Reserve(ctx context.Context, key, requestHash string, expiredBefore, lockedUntil time.Time) (*models.IdempotencyRecord, bool, error)
+	Save(ctx context.Context, key string, statusCode int, headers map[string]string, body []byte) error
+	Release(ctx context.Context, key string) error
+}
+
+// NewIdempotencyMiddleware makes requests carrying an Idempotency-Key header safe to retry.
+// The response to the first request, including its Content-Type and ETag headers, is stored and sent again to every following request
+// with the same key and body, or the same form for multipart requests, while reusing the key for another request is rejected with 422.
+// Keys are kept for ttl. Server errors are not stored, so such requests may be retried. A request being processed
+// holds its key for lease, retries get 409 meanwhile and take the key over if the response hasn't been stored by then.
+func NewIdempotencyMiddleware(log *slog.Logger, store Store, ttl, lease time.Duration) fiber.Handler {
+	log = log.With(
+		slog.String("component", "middleware/idempotency"),
+	)
+	log.Info("idempotency middleware enabled")
+	return func(c *fiber.Ctx) error {
+		key := c.Get(KeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+		log := log.With(
+			slog.String("request_id", requestID),
+			slog.String("idempotency_key", key),
+		)
+		if len(key) > maxKeyLength {
+			log.Error("idempotency key is too long")
+			return response.ErrorBadRequest(c, "Idempotency-Key must not be longer than 255 characters")
+		}
+
+		requestHash, err := hashRequest(c)
+		if err != nil {
+			log.Error("failed to read multipart form", sl.Err(err))
+			return response.ErrorBadRequest(c, "invalid multipart form")
+		}
+
+		now := time.Now()
+		rec, reserved, err := store.Reserve(c.UserContext(), key, requestHash, now.Add(-ttl), now.Add(lease))
+		if err != nil {
+			log.Error("failed to reserve idempotency key", sl.Err(err))
+			return response.ErrorInternal(c)
+		}
+		if !reserved {
+			if rec.RequestHash != requestHash {
+				log.Error("idempotency key reused for another request")
+				return response.ErrorUnprocessableEntity(c, "Idempotency-Key has already been used for another request")
+			}
+			if rec.StatusCode == 0 {
+				log.Error("request with the same idempotency key is in progress")
+				return response.ErrorConflict(c, "request with the same Idempotency-Key is being processed")
+			}
+			log.Info("replaying stored response", slog.Int("status", rec.StatusCode))
+			c.Set(ReplayedHeader, "true")
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			for name, value := range rec.Headers {
+				c.Set(name, value)
+			}
+			return c.Status(rec.StatusCode).Send(rec.Body)
+		}
+
+		err = c.Next()
+		status := c.Response().StatusCode()
+		if err != nil || status >= fiber.StatusInternalServerError {
+			if relErr := store.Release(c.UserContext(), key); relErr != nil {
+				log.Error("failed to release idempotency key", sl.Err(relErr))
+			}
+			return err
+		}
+		// the body buffer is reused by fasthttp after the response is sent
+		body := append([]byte(nil), c.Response().Body()...)
+		headers := make(map[string]string)
+		for _, name := range storedHeaders {
+			if value := c.GetRespHeader(name); value != "" {
+				headers[name] = value
+			}
+		}
+		if err = store.Save(c.UserContext(), key, status, headers, body); err != nil {
+			// the key is released, so that retries aren't rejected until the lease expires
+			log.Error("failed to save response", sl.Err(err))
+			if relErr := store.Release(c.UserContext(), key); relErr != nil {
+				log.Error("failed to release idempotency key", sl.Err(relErr))
+			}
+		}
+		return nil
+	}
+}
+
+// hashRequest identifies the request the key is used for. A multipart body contains a boundary the client
+// chooses anew for every retry, so the form fields and files are hashed instead of the body.
+func hashRequest(c *fiber.Ctx) (string, error) {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method() + " " + c.Path() + "\n"))
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		hash.Write(c.Body())
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range slices.Sorted(maps.Keys(form.Value)) {
+		for _, value := range form.Value[name] {
+			fmt.Fprintf(hash, "%q=%q\n", name, value)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(form.File)) {
+		for _, header := range form.File[name] {
+			fmt.Fprintf(hash, "%q=%q:%d\n", name, header.Filename, header.Size)
+			file, err := header.Open()
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(hash, file)
+			file.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
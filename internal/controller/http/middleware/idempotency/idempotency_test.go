@@ -0,0 +1,273 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryStore keeps the keys in a map, the records are never expired.
+type memoryStore struct {
+	records map[string]*models.IdempotencyRecord
+	saveErr error
+}
+
+func (s *memoryStore) Reserve(_ context.Context, key, requestHash string, _, _ time.Time) (*models.IdempotencyRecord, bool, error) {
+	if rec, ok := s.records[key]; ok {
+		return rec, false, nil
+	}
+	s.records[key] = &models.IdempotencyRecord{Key: key, RequestHash: requestHash}
+	return s.records[key], true, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, key string, statusCode int, headers map[string]string, body []byte) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	rec := s.records[key]
+	rec.StatusCode, rec.Headers, rec.Body = statusCode, headers, body
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, key string) error {
+	delete(s.records, key)
+	return nil
+}
+
+func hashOf(method, path, body string) string {
+	hash := sha256.Sum256([]byte(method + " " + path + "\n" + body))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	const body = `{"title":"Buy milk"}`
+	stored := &models.IdempotencyRecord{
+		Key:         "key",
+		RequestHash: hashOf(fiber.MethodPost, "/tasks", body),
+		StatusCode:  fiber.StatusCreated,
+		Headers:     map[string]string{fiber.HeaderETag: `"1"`},
+		Body:        []byte(`{"id":1}`),
+	}
+	tests := []struct {
+		name       string
+		key        string
+		body       string
+		records    map[string]*models.IdempotencyRecord
+		saveErr    error
+		status     int
+		wantStatus int
+		wantBody   string
+		wantETag   string
+		wantCalled bool
+		wantStored *models.IdempotencyRecord
+	}{
+		{
+			name:       "request without a key",
+			body:       body,
+			status:     fiber.StatusCreated,
+			wantStatus: fiber.StatusCreated,
+			wantBody:   `{"id":1}`,
+			wantETag:   `"1"`,
+			wantCalled: true,
+		},
+		{
+			name:       "response to the first request is stored",
+			key:        "key",
+			body:       body,
+			status:     fiber.StatusCreated,
+			wantStatus: fiber.StatusCreated,
+			wantBody:   `{"id":1}`,
+			wantETag:   `"1"`,
+			wantCalled: true,
+			wantStored: stored,
+		},
+		{
+			name:       "stored response is replayed",
+			key:        "key",
+			body:       body,
+			records:    map[string]*models.IdempotencyRecord{"key": stored},
+			wantStatus: fiber.StatusCreated,
+			wantBody:   `{"id":1}`,
+			wantETag:   `"1"`,
+			wantStored: stored,
+		},
+		{
+			name: "request with the same key is in progress",
+			key:  "key",
+			body: body,
+			records: map[string]*models.IdempotencyRecord{
+				"key": {Key: "key", RequestHash: stored.RequestHash},
+			},
+			wantStatus: fiber.StatusConflict,
+			wantStored: &models.IdempotencyRecord{Key: "key", RequestHash: stored.RequestHash},
+		},
+		{
+			name:       "key is used for another request",
+			key:        "key",
+			body:       `{"title":"Call mom"}`,
+			records:    map[string]*models.IdempotencyRecord{"key": stored},
+			wantStatus: fiber.StatusUnprocessableEntity,
+			wantStored: stored,
+		},
+		{
+			name:       "key is released after a server error",
+			key:        "key",
+			body:       body,
+			status:     fiber.StatusInternalServerError,
+			wantStatus: fiber.StatusInternalServerError,
+			wantBody:   `{"id":1}`,
+			wantETag:   `"1"`,
+			wantCalled: true,
+		},
+		{
+			name:       "key is released if the response can't be stored",
+			key:        "key",
+			body:       body,
+			saveErr:    errors.New("connection lost"),
+			status:     fiber.StatusCreated,
+			wantStatus: fiber.StatusCreated,
+			wantBody:   `{"id":1}`,
+			wantETag:   `"1"`,
+			wantCalled: true,
+		},
+		{
+			name:       "key is too long",
+			key:        strings.Repeat("k", maxKeyLength+1),
+			body:       body,
+			wantStatus: fiber.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{records: make(map[string]*models.IdempotencyRecord), saveErr: tt.saveErr}
+			for key, rec := range tt.records {
+				copied := *rec
+				store.records[key] = &copied
+			}
+			called := false
+			app := fiber.New()
+			app.Use(request_id.NewRequestIDMiddleware())
+			app.Post("/tasks", NewIdempotencyMiddleware(slog.New(slog.DiscardHandler), store, time.Hour, time.Minute), func(c *fiber.Ctx) error {
+				called = true
+				c.Set(fiber.HeaderETag, `"1"`)
+				c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return c.Status(tt.status).SendString(`{"id":1}`)
+			})
+
+			req := httptest.NewRequest(fiber.MethodPost, "/tasks", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.key != "" {
+				req.Header.Set(KeyHeader, tt.key)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			respBody, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", resp.StatusCode, tt.wantStatus, respBody)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantBody != "" && string(respBody) != tt.wantBody {
+				t.Errorf("body = %s, want %s", respBody, tt.wantBody)
+			}
+			if etag := resp.Header.Get(fiber.HeaderETag); etag != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", etag, tt.wantETag)
+			}
+			replayed := resp.Header.Get(ReplayedHeader) == "true"
+			if wantReplayed := tt.wantBody != "" && !tt.wantCalled; replayed != wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, wantReplayed)
+			}
+
+			rec := store.records["key"]
+			switch {
+			case tt.wantStored == nil && rec != nil:
+				t.Errorf("stored %+v, want the key released", rec)
+			case tt.wantStored != nil && rec == nil:
+				t.Errorf("key is released, want %+v", tt.wantStored)
+			case tt.wantStored != nil && (rec.RequestHash != tt.wantStored.RequestHash || rec.StatusCode != tt.wantStored.StatusCode ||
+				string(rec.Body) != string(tt.wantStored.Body) || rec.Headers[fiber.HeaderETag] != tt.wantStored.Headers[fiber.HeaderETag]):
+				t.Errorf("stored %+v, want %+v", rec, tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareMultipart(t *testing.T) {
+	form := func(boundary, dryRun string) string {
+		return "--" + boundary + "\r\n" +
+			"Content-Disposition: form-data; name=\"dry_run\"\r\n\r\n" + dryRun + "\r\n" +
+			"--" + boundary + "\r\n" +
+			"Content-Disposition: form-data; name=\"file\"; filename=\"tasks.csv\"\r\n" +
+			"Content-Type: text/csv\r\n\r\n" +
+			"title\nBuy milk\n\r\n" +
+			"--" + boundary + "--\r\n"
+	}
+	tests := []struct {
+		name       string
+		boundary   string
+		dryRun     string
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "retry with another boundary is replayed",
+			boundary:   "retry",
+			dryRun:     "true",
+			wantStatus: fiber.StatusOK,
+			wantCalls:  1,
+		},
+		{
+			name:       "another form field is another request",
+			boundary:   "first",
+			dryRun:     "false",
+			wantStatus: fiber.StatusUnprocessableEntity,
+			wantCalls:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{records: make(map[string]*models.IdempotencyRecord)}
+			calls := 0
+			app := fiber.New()
+			app.Use(request_id.NewRequestIDMiddleware())
+			app.Post("/tasks/import", NewIdempotencyMiddleware(slog.New(slog.DiscardHandler), store, time.Hour, time.Minute), func(c *fiber.Ctx) error {
+				calls++
+				return c.JSON(fiber.Map{"accepted": 1})
+			})
+			send := func(boundary, dryRun string) *http.Response {
+				req := httptest.NewRequest(fiber.MethodPost, "/tasks/import", strings.NewReader(form(boundary, dryRun)))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEMultipartForm+"; boundary="+boundary)
+				req.Header.Set(KeyHeader, "key")
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatalf("app.Test() error = %v", err)
+				}
+				return resp
+			}
+
+			if resp := send("first", "true"); resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status of the first request = %d, want %d", resp.StatusCode, fiber.StatusOK)
+			}
+			if resp := send(tt.boundary, tt.dryRun); resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/igorgrichanov/toDoList/internal/config"
 	"github.com/igorgrichanov/toDoList/internal/controller"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/actor"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/idempotency"
 	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/logger"
 	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
 	swagger "github.com/swaggo/fiber-swagger"
@@ -23,7 +25,15 @@ import (
 
 // @Tag.name			tasks
 // @Tag.description	operations with the list of tasks
-func NewRouter(log *slog.Logger, cfg *config.Server, ctrl *controller.Controllers) *fiber.App {
+// @Tag.name			tags
+// @Tag.description	labels used to group tasks
+// @Tag.name			projects
+// @Tag.description	lists the tasks belong to
+// @Tag.name			statuses
+// @Tag.description	columns of the board tasks move through
+// @Tag.name			comments
+// @Tag.description	discussion of tasks
+func NewRouter(log *slog.Logger, cfg *config.Server, ctrl *controller.Controllers, keys idempotency.Store) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
@@ -31,13 +41,62 @@ func NewRouter(log *slog.Logger, cfg *config.Server, ctrl *controller.Controller
 	})
 	app.Use(recover.New())
 	app.Use(request_id.NewRequestIDMiddleware())
+	app.Use(actor.NewActorMiddleware())
 	app.Use(logger.NewLoggerMiddleware(log))
 
+	idempotent := idempotency.NewIdempotencyMiddleware(log, keys, cfg.IdempotencyKeyTTL, cfg.IdempotencyKeyLease)
+
 	tasks := app.Group("/tasks")
-	tasks.Post("/", ctrl.Tasks.Create)
-	tasks.Get("/", ctrl.Tasks.List)
-	tasks.Put("/:id", ctrl.Tasks.Update)
+	tasks.Post("/", ctrl.Statuses.Load, idempotent, ctrl.Tasks.Create)
+	tasks.Post("/bulk", ctrl.Statuses.Load, idempotent, ctrl.Tasks.Bulk)
+	tasks.Post("/import", ctrl.Statuses.Load, idempotent, ctrl.Tasks.Import)
+	tasks.Get("/", ctrl.Statuses.Load, ctrl.Tasks.List)
+	tasks.Get("/trash", ctrl.Statuses.Load, ctrl.Tasks.Trash)
+	tasks.Delete("/trash", ctrl.Tasks.Purge)
+	tasks.Get("/search", ctrl.Tasks.Search)
+	tasks.Get("/:id", ctrl.Tasks.Get)
+	tasks.Put("/:id", ctrl.Statuses.Load, ctrl.Tasks.Update)
+	tasks.Patch("/:id", ctrl.Statuses.Load, ctrl.Tasks.Patch)
 	tasks.Delete("/:id", ctrl.Tasks.Delete)
+	tasks.Post("/:id/restore", ctrl.Tasks.Restore)
+	tasks.Post("/:id/undelete", ctrl.Tasks.Undelete)
+	tasks.Post("/:id/revert", ctrl.Tasks.Revert)
+	tasks.Post("/:id/transitions", ctrl.Statuses.Load, ctrl.Tasks.Transition)
+	tasks.Post("/:id/move", ctrl.Statuses.Load, ctrl.Tasks.Move)
+	tasks.Get("/:id/children", ctrl.Tasks.Subtasks)
+	tasks.Get("/:id/subtree", ctrl.Tasks.Subtree)
+	tasks.Get("/:id/history", ctrl.Tasks.History)
+	tasks.Post("/:id/dependencies", ctrl.Tasks.AddDependency)
+	tasks.Delete("/:id/dependencies/:blocked_by", ctrl.Tasks.RemoveDependency)
+	tasks.Get("/:id/comments", ctrl.Comments.List)
+	tasks.Post("/:id/comments", ctrl.Comments.Create)
+	tasks.Put("/:id/comments/:comment_id", ctrl.Comments.Update)
+	tasks.Delete("/:id/comments/:comment_id", ctrl.Comments.Delete)
+
+	series := app.Group("/series")
+	series.Get("/:id", ctrl.Tasks.Series)
+	series.Patch("/:id", ctrl.Tasks.UpdateSeries)
+	series.Delete("/:id", ctrl.Tasks.StopSeries)
+
+	tags := app.Group("/tags")
+	tags.Post("/", ctrl.Tags.Create)
+	tags.Get("/", ctrl.Tags.List)
+	tags.Get("/:id", ctrl.Tags.Get)
+	tags.Put("/:id", ctrl.Tags.Update)
+	tags.Delete("/:id", ctrl.Tags.Delete)
+
+	statuses := app.Group("/statuses")
+	statuses.Post("/", ctrl.Statuses.Create)
+	statuses.Get("/", ctrl.Statuses.List)
+
+	projects := app.Group("/projects")
+	projects.Post("/", ctrl.Projects.Create)
+	projects.Get("/", ctrl.Projects.List)
+	projects.Get("/:id", ctrl.Projects.Get)
+	projects.Put("/:id", ctrl.Projects.Update)
+	projects.Delete("/:id", ctrl.Projects.Delete)
+	projects.Get("/:id/tasks", ctrl.Statuses.Load, ctrl.Tasks.ListInProject)
+	projects.Post("/:id/tasks", ctrl.Statuses.Load, idempotent, ctrl.Tasks.CreateInProject)
 
 	sw := app.Group("/swagger")
 	sw.Use(func(c *fiber.Ctx) error {
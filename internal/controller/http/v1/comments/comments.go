@@ -0,0 +1,253 @@
+package comments
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+type Commenter interface {
+	Create(c *fiber.Ctx) error
+	List(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+}
+
+type CommentController struct {
+	log       *slog.Logger
+	uc        service.Comments
+	validator *validator.Validate
+}
+
+func NewCommentController(log *slog.Logger, uc service.Comments, v *validator.Validate) *CommentController {
+	return &CommentController{log: log, uc: uc, validator: v}
+}
+
+type CommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+const defaultListLimit = 20
+
+type ListRequest struct {
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	After string `query:"after"`
+}
+
+// @Summary	Add a comment to the task
+// @Tags		comments
+// @Param		id		path		int				true	"Task ID"
+// @Param		Comment	body		CommentRequest	true	"Text of the comment"
+// @Success	201		{object}	models.Comment
+// @Failure	400		{object}	response.Response	"invalid request body or task ID"
+// @Failure	404		{object}	response.Response	"task not found"
+// @Failure	500		{object}	response.Response	"internal server error"
+// @Router		/tasks/{id}/comments [post]
+func (cc *CommentController) Create(c *fiber.Ctx) error {
+	const op = "controller.comments.Create"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := cc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	taskID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || taskID < 1 {
+		log.Error("invalid task id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	req := &CommentRequest{}
+	if err = c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if err = cc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	log.Info("request received", slog.Int("task_id", taskID))
+
+	comment, err := cc.uc.CreateComment(c.UserContext(), &models.Comment{TaskID: taskID, Body: req.Body})
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to create comment", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("comment created", slog.Int("id", comment.ID))
+
+	return c.Status(fiber.StatusCreated).JSON(comment)
+}
+
+// @Summary		Get comments of the task
+// @Description	Comments are returned from the oldest to the newest.
+// @Tags			comments
+// @Param			id		path		int		true	"Task ID"
+// @Param			limit	query		int		false	"Number of comments per page, 20 by default"	minimum(1)	maximum(100)
+// @Param			after	query		string	false	"Cursor returned as next_cursor of the previous page"
+// @Success		200		{object}	models.CommentPage
+// @Failure		400		{object}	response.Response	"invalid task ID or query parameters"
+// @Failure		404		{object}	response.Response	"task not found"
+// @Failure		500		{object}	response.Response	"internal server error"
+// @Router			/tasks/{id}/comments [get]
+func (cc *CommentController) List(c *fiber.Ctx) error {
+	const op = "controller.comments.List"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := cc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	taskID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || taskID < 1 {
+		log.Error("invalid task id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	req := &ListRequest{}
+	if err = c.QueryParser(req); err != nil {
+		log.Error("failed to parse query params", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid query parameters")
+	}
+	if err = cc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "query parameters validation failed")
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	log.Info("request received", slog.Int("task_id", taskID), slog.Any("data", req))
+
+	page, err := cc.uc.ListComments(c.UserContext(), taskID, &models.Page{Limit: req.Limit, After: req.After})
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if errors.Is(err, service.ErrInvalidInput) {
+		log.Error("invalid cursor", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid cursor")
+	} else if err != nil {
+		log.Error("failed to list comments", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+
+	log.Info("comments received", slog.Int("count", len(page.Comments)), slog.Int("total", page.Total))
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// @Summary	Edit the comment
+// @Tags		comments
+// @Param		id			path		int				true	"Task ID"
+// @Param		comment_id	path		int				true	"Comment ID"
+// @Param		Comment		body		CommentRequest	true	"New text of the comment"
+// @Success	200			{object}	models.Comment
+// @Failure	400			{object}	response.Response	"invalid request body, task or comment ID"
+// @Failure	404			{object}	response.Response	"comment not found"
+// @Failure	500			{object}	response.Response	"internal server error"
+// @Router		/tasks/{id}/comments/{comment_id} [put]
+func (cc *CommentController) Update(c *fiber.Ctx) error {
+	const op = "controller.comments.Update"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := cc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	taskID, id, msg := parseIDs(c)
+	if msg != "" {
+		log.Error("invalid id params", slog.String("id", c.Params("id")), slog.String("comment_id", c.Params("comment_id")))
+		return response.ErrorBadRequest(c, msg)
+	}
+	req := &CommentRequest{}
+	if err := c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if err := cc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	log.Info("request received", slog.Int("task_id", taskID), slog.Int("id", id))
+
+	comment, err := cc.uc.UpdateComment(c.UserContext(), &models.Comment{ID: id, TaskID: taskID, Body: req.Body})
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("comment not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to update comment", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("comment updated", slog.Int("id", comment.ID))
+
+	return c.Status(fiber.StatusOK).JSON(comment)
+}
+
+// @Summary	Delete the comment
+// @Tags		comments
+// @Param		id			path		int	true	"Task ID"
+// @Param		comment_id	path		int	true	"Comment ID"
+// @Success	200			{object}	models.Comment
+// @Failure	400			{object}	response.Response	"invalid task or comment ID"
+// @Failure	404			{object}	response.Response	"comment not found"
+// @Failure	500			{object}	response.Response	"internal server error"
+// @Router		/tasks/{id}/comments/{comment_id} [delete]
+func (cc *CommentController) Delete(c *fiber.Ctx) error {
+	const op = "controller.comments.Delete"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := cc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	taskID, id, msg := parseIDs(c)
+	if msg != "" {
+		log.Error("invalid id params", slog.String("id", c.Params("id")), slog.String("comment_id", c.Params("comment_id")))
+		return response.ErrorBadRequest(c, msg)
+	}
+	log.Info("request received", slog.Int("task_id", taskID), slog.Int("id", id))
+
+	comment, err := cc.uc.DeleteComment(c.UserContext(), taskID, id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("comment not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to delete comment", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("comment deleted", slog.Int("id", comment.ID))
+
+	return c.Status(fiber.StatusOK).JSON(comment)
+}
+
+// parseIDs returns the task and comment ids from the path, a non-empty message means that one of them is invalid.
+func parseIDs(c *fiber.Ctx) (int, int, string) {
+	taskID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || taskID < 1 {
+		return 0, 0, "invalid task ID"
+	}
+	id, err := strconv.Atoi(c.Params("comment_id"))
+	if err != nil || id < 1 {
+		return 0, 0, "invalid comment ID"
+	}
+	return taskID, id, ""
+}
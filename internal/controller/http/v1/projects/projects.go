@@ -0,0 +1,267 @@
+package projects
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+type Projector interface {
+	Create(c *fiber.Ctx) error
+	List(c *fiber.Ctx) error
+	Get(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+}
+
+type ProjectController struct {
+	log       *slog.Logger
+	uc        service.Projects
+	validator *validator.Validate
+}
+
+func NewProjectController(log *slog.Logger, uc service.Projects, v *validator.Validate) *ProjectController {
+	return &ProjectController{log: log, uc: uc, validator: v}
+}
+
+type ProjectRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description,omitempty"`
+}
+
+// @Summary	Create a new project
+// @Tags		projects
+// @Param		Project	body		ProjectRequest	true	"Project name and optional description"
+// @Success	201		{object}	models.Project
+// @Failure	400		{object}	response.Response	"invalid request body"
+// @Failure	500		{object}	response.Response	"internal server error"
+// @Router		/projects [post]
+func (pc *ProjectController) Create(c *fiber.Ctx) error {
+	const op = "controller.projects.Create"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := pc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+
+	req := &ProjectRequest{}
+	if err := c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := pc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	log.Info("request received", slog.Any("data", req))
+
+	project, err := pc.uc.CreateProject(c.UserContext(), &models.Project{Name: req.Name, Description: req.Description})
+	if err != nil {
+		log.Error("failed to create project", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("project created", slog.Any("data", project))
+
+	return c.Status(fiber.StatusCreated).JSON(project)
+}
+
+// @Summary	Get list of projects
+// @Tags		projects
+// @Success	200	{object}	[]models.Project
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/projects [get]
+func (pc *ProjectController) List(c *fiber.Ctx) error {
+	const op = "controller.projects.List"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := pc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	log.Info("request received")
+
+	projects, err := pc.uc.ListProjects(c.UserContext())
+	if err != nil {
+		log.Error("failed to list projects", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+
+	log.Info("projects received", slog.Int("count", len(projects)))
+	return c.Status(fiber.StatusOK).JSON(projects)
+}
+
+// @Summary	Get project by ID
+// @Tags		projects
+// @Param		id	path		int	true	"Project ID"
+// @Success	200	{object}	models.Project
+// @Failure	400	{object}	response.Response	"invalid project ID"
+// @Failure	404	{object}	response.Response	"project not found"
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/projects/{id} [get]
+func (pc *ProjectController) Get(c *fiber.Ctx) error {
+	const op = "controller.projects.Get"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := pc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid project ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid project ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	project, err := pc.uc.GetProject(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to get project", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("project received", slog.Any("data", project))
+
+	return c.Status(fiber.StatusOK).JSON(project)
+}
+
+// @Summary	Update project
+// @Tags		projects
+// @Param		id		path		int				true	"Project ID"
+// @Param		Project	body		ProjectRequest	true	"New name and description"
+// @Success	200		{object}	models.Project
+// @Failure	400		{object}	response.Response	"invalid request body or project ID"
+// @Failure	404		{object}	response.Response	"project not found"
+// @Failure	500		{object}	response.Response	"internal server error"
+// @Router		/projects/{id} [put]
+func (pc *ProjectController) Update(c *fiber.Ctx) error {
+	const op = "controller.projects.Update"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := pc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid project ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid project ID")
+	}
+	req := &ProjectRequest{}
+	if err := c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err = pc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	log.Info("request received", slog.Any("data", req))
+
+	project, err := pc.uc.UpdateProject(c.UserContext(), &models.Project{ID: id, Name: req.Name, Description: req.Description})
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to update project", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("project updated", slog.Any("data", project))
+
+	return c.Status(fiber.StatusOK).JSON(project)
+}
+
+type DeleteRequest struct {
+	Mode string `query:"mode" validate:"omitempty,oneof=reject cascade inbox"`
+}
+
+// @Summary		Delete project
+// @Description	What happens to tasks of the project depends on the mode, the server default is used if it is omitted:
+// @Description	reject refuses to delete a project with tasks (tasks in the trash are moved to the inbox),
+// @Description	cascade moves the tasks to the trash without a project, inbox leaves the tasks without a project.
+// @Tags			projects
+// @Param			id		path		int		true	"Project ID"
+// @Param			mode	query		string	false	"How to handle tasks of the project"	Enums(reject, cascade, inbox)
+// @Success		200		{object}	models.Project
+// @Failure		400		{object}	response.Response	"invalid project ID or mode"
+// @Failure		404		{object}	response.Response	"project not found"
+// @Failure		409		{object}	response.Response	"project has tasks"
+// @Failure		500		{object}	response.Response	"internal server error"
+// @Router			/projects/{id} [delete]
+func (pc *ProjectController) Delete(c *fiber.Ctx) error {
+	const op = "controller.projects.Delete"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := pc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid project ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid project ID")
+	}
+	req := &DeleteRequest{}
+	if err = c.QueryParser(req); err != nil {
+		log.Error("failed to parse query params", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid query parameters")
+	}
+	req.Mode = strings.ToLower(req.Mode)
+	if err = pc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "query parameters validation failed")
+	}
+	log.Info("request received", slog.Int("id", id), slog.String("mode", req.Mode))
+
+	project, err := pc.uc.DeleteProject(c.UserContext(), id, models.ProjectDeleteMode(req.Mode))
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if errors.Is(err, service.ErrNotEmpty) {
+		log.Error("project has tasks", sl.Err(err))
+		return response.ErrorConflict(c, "project has tasks")
+	} else if err != nil {
+		log.Error("failed to delete project", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("project deleted", slog.Any("data", project))
+
+	return c.Status(fiber.StatusOK).JSON(project)
+}
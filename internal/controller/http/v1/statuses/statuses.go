@@ -0,0 +1,170 @@
+package statuses
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+type Statuser interface {
+	Create(c *fiber.Ctx) error
+	List(c *fiber.Ctx) error
+	Load(c *fiber.Ctx) error
+}
+
+type StatusController struct {
+	log       *slog.Logger
+	uc        service.Statuses
+	validator *validator.Validate
+}
+
+func NewStatusController(log *slog.Logger, uc service.Statuses, v *validator.Validate) *StatusController {
+	return &StatusController{log: log, uc: uc, validator: v}
+}
+
+// ctxKeyStatuses is the key of the status names loaded for the request.
+type ctxKeyStatuses int
+
+const statusesKey ctxKeyStatuses = 0
+
+// RegisterValidation adds the status tag checking that the field is the name of an existing status.
+// Structs having the tag must be validated with StructCtx and the request context, the statuses
+// are taken from the context if they have been loaded by Load.
+func RegisterValidation(v *validator.Validate, uc service.Statuses) error {
+	return v.RegisterValidationCtx("status", func(ctx context.Context, fl validator.FieldLevel) bool {
+		names, ok := ctx.Value(statusesKey).(map[string]bool)
+		if !ok {
+			statuses, err := uc.ListStatuses(ctx)
+			if err != nil {
+				return false
+			}
+			names = statusNames(statuses)
+		}
+		return names[fl.Field().String()]
+	})
+}
+
+func statusNames(statuses []*models.Status) map[string]bool {
+	names := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		names[status.Name] = true
+	}
+	return names
+}
+
+// Load is a middleware putting the statuses into the request context before the handlers validate
+// the status fields, so that they are read once per request and a failure is reported as 500.
+func (sc *StatusController) Load(c *fiber.Ctx) error {
+	const op = "controller.statuses.Load"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := sc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+
+	statuses, err := sc.uc.ListStatuses(c.UserContext())
+	if err != nil {
+		log.Error("failed to list statuses", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	c.SetUserContext(context.WithValue(c.UserContext(), statusesKey, statusNames(statuses)))
+	return c.Next()
+}
+
+// statusName keeps status names usable in query parameters and in the workflow configuration.
+var statusName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type StatusRequest struct {
+	Name     string `json:"name" validate:"required,max=30"`
+	Position int    `json:"position,omitempty" validate:"omitempty,min=1"`
+	Terminal bool   `json:"terminal,omitempty"`
+}
+
+// @Summary		Create a new status
+// @Description	Statuses are the columns of the board, tasks in terminal statuses are considered finished.
+// @Description	The status is put after the existing ones if position is omitted.
+// @Tags			statuses
+// @Param			Status	body		StatusRequest	true	"Lowercase status name of letters, digits and underscores"
+// @Success		201		{object}	models.Status
+// @Failure		400		{object}	response.Response	"invalid request body"
+// @Failure		409		{object}	response.Response	"status already exists"
+// @Failure		500		{object}	response.Response	"internal server error"
+// @Router			/statuses [post]
+func (sc *StatusController) Create(c *fiber.Ctx) error {
+	const op = "controller.statuses.Create"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := sc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+
+	req := &StatusRequest{}
+	if err := c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.Name = strings.ToLower(strings.TrimSpace(req.Name))
+	if err := sc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	if !statusName.MatchString(req.Name) {
+		log.Error("invalid status name", slog.String("name", req.Name))
+		return response.ErrorBadRequest(c, "field 'name' must start with a letter and contain only letters, digits and underscores")
+	}
+	log.Info("request received", slog.Any("data", req))
+
+	status, err := sc.uc.CreateStatus(c.UserContext(), &models.Status{
+		Name:     req.Name,
+		Position: req.Position,
+		Terminal: req.Terminal,
+	})
+	if errors.Is(err, service.ErrConflict) {
+		log.Error("status already exists", sl.Err(err))
+		return response.ErrorConflict(c, "status already exists")
+	} else if err != nil {
+		log.Error("failed to create status", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("status created", slog.Any("data", status))
+
+	return c.Status(fiber.StatusCreated).JSON(status)
+}
+
+// @Summary	Get list of statuses
+// @Tags		statuses
+// @Success	200	{object}	[]models.Status
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/statuses [get]
+func (sc *StatusController) List(c *fiber.Ctx) error {
+	const op = "controller.statuses.List"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := sc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	log.Info("request received")
+
+	statuses, err := sc.uc.ListStatuses(c.UserContext())
+	if err != nil {
+		log.Error("failed to list statuses", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+
+	log.Info("statuses received", slog.Int("count", len(statuses)))
+	return c.Status(fiber.StatusOK).JSON(statuses)
+}
@@ -0,0 +1,247 @@
+package tags
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+)
+
+type Tagger interface {
+	Create(c *fiber.Ctx) error
+	List(c *fiber.Ctx) error
+	Get(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+}
+
+type TagController struct {
+	log       *slog.Logger
+	uc        service.Tags
+	validator *validator.Validate
+}
+
+func NewTagController(log *slog.Logger, uc service.Tags, v *validator.Validate) *TagController {
+	return &TagController{log: log, uc: uc, validator: v}
+}
+
+type TagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// @Summary	Create a new tag
+// @Tags		tags
+// @Param		Tag	body		TagRequest	true	"Tag name, case-insensitive"
+// @Success	201	{object}	models.Tag
+// @Failure	400	{object}	response.Response	"invalid request body"
+// @Failure	409	{object}	response.Response	"tag already exists"
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/tags [post]
+func (tc *TagController) Create(c *fiber.Ctx) error {
+	const op = "controller.tags.Create"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+
+	req := &TagRequest{}
+	if err := c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.Name = models.NormalizeTagName(req.Name)
+	if err := tc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	log.Info("request received", slog.Any("data", req))
+
+	tag, err := tc.uc.CreateTag(c.UserContext(), &models.Tag{Name: req.Name})
+	if errors.Is(err, service.ErrConflict) {
+		log.Error("tag already exists", sl.Err(err))
+		return response.ErrorConflict(c, "tag already exists")
+	} else if err != nil {
+		log.Error("failed to create tag", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("tag created", slog.Any("data", tag))
+
+	return c.Status(fiber.StatusCreated).JSON(tag)
+}
+
+// @Summary	Get list of tags
+// @Tags		tags
+// @Success	200	{object}	[]models.Tag
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/tags [get]
+func (tc *TagController) List(c *fiber.Ctx) error {
+	const op = "controller.tags.List"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	log.Info("request received")
+
+	tags, err := tc.uc.ListTags(c.UserContext())
+	if err != nil {
+		log.Error("failed to list tags", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+
+	log.Info("tags received", slog.Int("count", len(tags)))
+	return c.Status(fiber.StatusOK).JSON(tags)
+}
+
+// @Summary	Get tag by ID
+// @Tags		tags
+// @Param		id	path		int	true	"Tag ID"
+// @Success	200	{object}	models.Tag
+// @Failure	400	{object}	response.Response	"invalid tag ID"
+// @Failure	404	{object}	response.Response	"tag not found"
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/tags/{id} [get]
+func (tc *TagController) Get(c *fiber.Ctx) error {
+	const op = "controller.tags.Get"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid tag ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid tag ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	tag, err := tc.uc.GetTag(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("tag not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to get tag", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("tag received", slog.Any("data", tag))
+
+	return c.Status(fiber.StatusOK).JSON(tag)
+}
+
+// @Summary		Rename tag
+// @Description	Tasks keep the tag under its new name.
+// @Tags			tags
+// @Param			id	path		int			true	"Tag ID"
+// @Param			Tag	body		TagRequest	true	"New tag name"
+// @Success		200	{object}	models.Tag
+// @Failure		400	{object}	response.Response	"invalid request body or tag ID"
+// @Failure		404	{object}	response.Response	"tag not found"
+// @Failure		409	{object}	response.Response	"tag with this name already exists"
+// @Failure		500	{object}	response.Response	"internal server error"
+// @Router			/tags/{id} [put]
+func (tc *TagController) Update(c *fiber.Ctx) error {
+	const op = "controller.tags.Update"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid tag ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid tag ID")
+	}
+	req := &TagRequest{}
+	if err := c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.Name = models.NormalizeTagName(req.Name)
+	if err = tc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	log.Info("request received", slog.Any("data", req))
+
+	tag, err := tc.uc.UpdateTag(c.UserContext(), &models.Tag{ID: id, Name: req.Name})
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("tag not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if errors.Is(err, service.ErrConflict) {
+		log.Error("tag already exists", sl.Err(err))
+		return response.ErrorConflict(c, "tag with this name already exists")
+	} else if err != nil {
+		log.Error("failed to update tag", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("tag updated", slog.Any("data", tag))
+
+	return c.Status(fiber.StatusOK).JSON(tag)
+}
+
+// @Summary		Delete tag
+// @Description	The tag is removed from all tasks.
+// @Tags			tags
+// @Param			id	path		int	true	"Tag ID"
+// @Success		200	{object}	models.Tag
+// @Failure		400	{object}	response.Response	"invalid tag ID"
+// @Failure		404	{object}	response.Response	"tag not found"
+// @Failure		500	{object}	response.Response	"internal server error"
+// @Router			/tags/{id} [delete]
+func (tc *TagController) Delete(c *fiber.Ctx) error {
+	const op = "controller.tags.Delete"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid tag ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid tag ID")
+	}
+
+	tag, err := tc.uc.DeleteTag(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("tag not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to delete tag", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("tag deleted", slog.Any("data", tag))
+
+	return c.Status(fiber.StatusOK).JSON(tag)
+}
@@ -0,0 +1,203 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	bulkAtomic     = "atomic"
+	bulkBestEffort = "best_effort"
+)
+
+type BulkRequest struct {
+	// Mode is atomic by default: all operations are applied or none of them.
+	// In best_effort mode the failed operations are skipped.
+	Mode       string                 `json:"mode,omitempty" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []BulkOperationRequest `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+type BulkOperationRequest struct {
+	Op string `json:"op" validate:"required,oneof=create update patch delete" enums:"create,update,patch,delete"`
+	// ID of the task, required by all operations except create.
+	ID int `json:"id,omitempty" validate:"min=0"`
+	// Version is checked like the If-Match header of the single requests, 0 skips the check.
+	Version int `json:"version,omitempty" validate:"min=0"`
+	// Task is the body of the single request: CreateRequest, UpdateRequest or PatchRequest.
+	Task json.RawMessage `json:"task,omitempty" swaggertype:"object"`
+}
+
+type BulkResponse struct {
+	Results []BulkResult `json:"results"`
+	Applied int          `json:"applied"`
+	Failed  int          `json:"failed"`
+}
+
+// BulkResult is the outcome of the operation with the same index.
+type BulkResult struct {
+	Index int `json:"index"`
+	// Status is the HTTP status code the single request would get,
+	// 424 means that the operation has not been applied because another one failed in atomic mode.
+	Status  int          `json:"status"`
+	Task    *models.Task `json:"task,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+// @Summary		Apply several operations to tasks
+// @Description	Operations are applied in one transaction in the order they are listed. In atomic mode (default)
+// @Description	a failed operation rolls back all of them, in best_effort mode only the failed operations are skipped.
+// @Description	Each result holds the status code and the error message the single request would get.
+// @Description	Requests with an Idempotency-Key header may be safely retried.
+// @Tags			tasks
+// @Param			Idempotency-Key	header		string		false	"Unique key of the request, up to 255 characters"
+// @Param			Operations		body		BulkRequest	true	"Operations to apply"
+// @Success		200				{object}	BulkResponse
+// @Failure		400				{object}	response.Response	"invalid request body"
+// @Failure		409				{object}	response.Response	"request with the same Idempotency-Key is being processed"
+// @Failure		422				{object}	response.Response	"Idempotency-Key has already been used for another request"
+// @Failure		500				{object}	response.Response	"internal server error"
+// @Router			/tasks/bulk [post]
+func (tc *TaskController) Bulk(c *fiber.Ctx) error {
+	const op = "controller.tasks.Bulk"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	req := &BulkRequest{}
+	if err := c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	if err := tc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	if req.Mode == "" {
+		req.Mode = bulkAtomic
+	}
+	log.Info("request received", slog.String("mode", req.Mode), slog.Int("count", len(req.Operations)))
+
+	// invalid operations get their results right away, the valid ones are passed to the service
+	results := make([]BulkResult, len(req.Operations))
+	ops := make([]*models.BulkOperation, 0, len(req.Operations))
+	indexes := make([]int, 0, len(req.Operations))
+	for i := range req.Operations {
+		operation, msg := tc.bulkOperation(c.UserContext(), &req.Operations[i])
+		if msg != "" {
+			log.Error("invalid operation", slog.Int("index", i), slog.String("reason", msg))
+			results[i] = BulkResult{Index: i, Status: http.StatusBadRequest, Message: msg}
+			continue
+		}
+		ops = append(ops, operation)
+		indexes = append(indexes, i)
+	}
+
+	atomic := req.Mode == bulkAtomic
+	if atomic && len(ops) < len(req.Operations) {
+		for _, i := range indexes {
+			results[i] = bulkResult(i, req.Operations[i].Op, &models.BulkResult{Err: service.ErrNotApplied})
+		}
+	} else if len(ops) > 0 {
+		applied, err := tc.uc.BulkTasks(c.UserContext(), ops, atomic)
+		if err != nil {
+			log.Error("failed to apply operations", sl.Err(err))
+			return response.ErrorInternal(c)
+		}
+		for j, res := range applied {
+			i := indexes[j]
+			results[i] = bulkResult(i, req.Operations[i].Op, res)
+		}
+	}
+
+	resp := BulkResponse{Results: results}
+	for _, res := range results {
+		if res.Status < http.StatusBadRequest {
+			resp.Applied++
+		} else {
+			resp.Failed++
+		}
+	}
+	log.Info("operations applied", slog.Int("applied", resp.Applied), slog.Int("failed", resp.Failed))
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// bulkOperation validates the operation the same way as the single request and converts it.
+// A non-empty message is returned if the operation is invalid.
+func (tc *TaskController) bulkOperation(ctx context.Context, req *BulkOperationRequest) (*models.BulkOperation, string) {
+	operation := &models.BulkOperation{Action: models.BulkAction(req.Op), ID: req.ID, Version: req.Version}
+	if operation.Action != models.BulkCreate && req.ID == 0 {
+		return nil, "field 'id' is required"
+	}
+	if operation.Action != models.BulkDelete && len(req.Task) == 0 {
+		return nil, "field 'task' is required"
+	}
+
+	var err error
+	switch operation.Action {
+	case models.BulkCreate:
+		task := &CreateRequest{}
+		if err = json.Unmarshal(req.Task, task); err != nil {
+			return nil, "invalid task"
+		}
+		task.normalize()
+		if err = tc.validator.StructCtx(ctx, task); err == nil {
+			operation.Task = task.task()
+		}
+	case models.BulkUpdate:
+		task := &UpdateRequest{}
+		if err = json.Unmarshal(req.Task, task); err != nil {
+			return nil, "invalid task"
+		}
+		task.normalize()
+		if err = tc.validator.StructCtx(ctx, task); err == nil {
+			operation.Task = task.task(req.ID, req.Version)
+		}
+	case models.BulkPatch:
+		patch, fields, msg := parsePatch(req.Task)
+		if msg != "" {
+			return nil, msg
+		}
+		if err = tc.validator.StructCtx(ctx, patch); err == nil {
+			operation.Patch = patch.patch(req.ID, req.Version, fields)
+		}
+	}
+	if err != nil {
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			return nil, response.ValidationError(validateErr)
+		}
+		return nil, "task validation failed"
+	}
+	return operation, ""
+}
+
+// bulkResult converts the outcome of the operation to the status code and the message of the single request.
+func bulkResult(index int, op string, res *models.BulkResult) BulkResult {
+	result := BulkResult{Index: index, Task: res.Task}
+	switch {
+	case res.Err != nil:
+		result.Status, result.Message = taskError(res.Err)
+	case op == string(models.BulkCreate):
+		result.Status = http.StatusCreated
+	default:
+		result.Status = http.StatusOK
+	}
+	return result
+}
@@ -0,0 +1,125 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+)
+
+type DependencyRequest struct {
+	BlockedBy int `json:"blocked_by" validate:"required,min=1"`
+}
+
+// @Summary		Add dependency
+// @Description	Makes the task blocked by another task: it can't leave the first status or reach a terminal status until the other task is done.
+// @Tags			tasks
+// @Param			id			path		int					true	"Task ID"
+// @Param			Dependency	body		DependencyRequest	true	"ID of the blocking task"
+// @Success		200			{object}	models.Task
+// @Header			200			{string}	ETag				"New version of the task"
+// @Failure		400			{object}	response.Response	"invalid request body or task ID"
+// @Failure		404			{object}	response.Response	"task not found"
+// @Failure		422			{object}	response.Response	"blocking task not found or dependency makes a cycle"
+// @Failure		500			{object}	response.Response	"internal server error"
+// @Router			/tasks/{id}/dependencies [post]
+func (tc *TaskController) AddDependency(c *fiber.Ctx) error {
+	const op = "controller.tasks.AddDependency"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	req := &DependencyRequest{}
+	if err = c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	if err = tc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	log.Info("request received", slog.Int("id", id), slog.Any("data", req))
+
+	task, err := tc.uc.AddDependency(c.UserContext(), id, req.BlockedBy)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if errors.Is(err, service.ErrRelatedNotFound) {
+		log.Error("blocking task not found", sl.Err(err))
+		return response.ErrorUnprocessableEntity(c, "blocking task not found")
+	} else if errors.Is(err, service.ErrDependencyCycle) {
+		log.Error("dependency makes a cycle", sl.Err(err))
+		return response.ErrorUnprocessableEntity(c, "dependency makes a cycle")
+	} else if err != nil {
+		log.Error("failed to add dependency", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("dependency added", slog.Any("data", task))
+
+	setETag(c, task)
+	return c.Status(fiber.StatusOK).JSON(task)
+}
+
+// @Summary	Remove dependency
+// @Tags		tasks
+// @Param		id			path		int	true	"Task ID"
+// @Param		blocked_by	path		int	true	"ID of the blocking task"
+// @Success	200			{object}	models.Task
+// @Header		200			{string}	ETag				"New version of the task"
+// @Failure	400			{object}	response.Response	"invalid task ID"
+// @Failure	404			{object}	response.Response	"dependency not found"
+// @Failure	500			{object}	response.Response	"internal server error"
+// @Router		/tasks/{id}/dependencies/{blocked_by} [delete]
+func (tc *TaskController) RemoveDependency(c *fiber.Ctx) error {
+	const op = "controller.tasks.RemoveDependency"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 0 {
+		log.Error("invalid id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	blockedBy, err := strconv.Atoi(c.Params("blocked_by"))
+	if err != nil || blockedBy < 0 {
+		log.Error("invalid blocked_by param", slog.String("blocked_by", c.Params("blocked_by")))
+		return response.ErrorBadRequest(c, "invalid blocking task ID")
+	}
+	log.Info("request received", slog.Int("id", id), slog.Int("blocked_by", blockedBy))
+
+	task, err := tc.uc.RemoveDependency(c.UserContext(), id, blockedBy)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("dependency not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to remove dependency", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("dependency removed", slog.Any("data", task))
+
+	setETag(c, task)
+	return c.Status(fiber.StatusOK).JSON(task)
+}
@@ -0,0 +1,42 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"net/http"
+)
+
+// taskError converts the error of a task operation to the status code and the message of the response,
+// so that single requests and operations of bulk requests report errors the same way.
+func taskError(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrNotApplied):
+		return http.StatusFailedDependency, err.Error()
+	case errors.Is(err, service.ErrNotFound), errors.Is(err, service.ErrVersionNotFound):
+		return http.StatusNotFound, http.StatusText(http.StatusNotFound)
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, http.StatusText(http.StatusPreconditionFailed)
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict, "task has already been updated, try again"
+	case errors.Is(err, service.ErrRelatedNotFound):
+		return http.StatusUnprocessableEntity, "project not found"
+	case errors.Is(err, service.ErrParentNotFound):
+		return http.StatusUnprocessableEntity, "parent task not found"
+	case errors.Is(err, service.ErrInvalidParent):
+		return http.StatusUnprocessableEntity, "task can't be a subtask of itself or of its subtasks"
+	case errors.Is(err, service.ErrOpenSubtasks):
+		return http.StatusUnprocessableEntity, "task has subtasks that are not done"
+	case errors.Is(err, service.ErrBlocked):
+		return http.StatusUnprocessableEntity, "task is blocked by tasks that are not done"
+	case errors.Is(err, service.ErrInvalidTransition):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, service.ErrInvalidPosition):
+		return http.StatusUnprocessableEntity, "after_id and before_id must be other tasks of the target column in this order"
+	case errors.Is(err, service.ErrInvalidInput):
+		return http.StatusBadRequest, "unknown status"
+	case errors.Is(err, service.ErrInvalidRecurrence):
+		return http.StatusBadRequest, "invalid recurrence rule"
+	default:
+		return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,50 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidIfMatch = errors.New("invalid If-Match header")
+	// weak entity tags never match in If-Match, see RFC 9110, section 13.1.1
+	errWeakIfMatch = errors.New("weak entity tag in If-Match header")
+)
+
+// setETag exposes the version of the task as a strong entity tag.
+func setETag(c *fiber.Ctx, task *models.Task) {
+	c.Set(fiber.HeaderETag, strconv.Quote(strconv.Itoa(task.Version)))
+}
+
+// ifMatchVersion returns the task version required by the If-Match header.
+// 0 is returned if the header is absent or equals "*", which matches any existing task.
+// Only a single entity tag previously sent in ETag is supported.
+func ifMatchVersion(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, errWeakIfMatch
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// ifMatchError sends the response for an error returned by ifMatchVersion.
+func ifMatchError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errWeakIfMatch) {
+		return response.ErrorPreconditionFailed(c)
+	}
+	return response.ErrorBadRequest(c, err.Error())
+}
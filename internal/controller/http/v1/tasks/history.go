@@ -0,0 +1,49 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+)
+
+// @Summary		Get change history of the task
+// @Description	Events are returned from the oldest to the newest, the history is kept after the task is deleted.
+// @Description	The actor of the change is taken from the X-Actor header of the request that made it.
+// @Tags			tasks
+// @Param			id	path		int	true	"Task ID"
+// @Success		200	{object}	[]models.TaskEvent
+// @Failure		400	{object}	response.Response	"invalid task ID"
+// @Failure		404	{object}	response.Response	"task not found"
+// @Failure		500	{object}	response.Response	"internal server error"
+// @Router			/tasks/{id}/history [get]
+func (tc *TaskController) History(c *fiber.Ctx) error {
+	const op = "controller.tasks.History"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("invalid id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	events, err := tc.uc.GetHistory(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to get task history", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("task history received", slog.Int("count", len(events)))
+
+	return c.Status(fiber.StatusOK).JSON(events)
+}
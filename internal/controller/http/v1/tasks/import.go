@@ -0,0 +1,292 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	importCSV    = "csv"
+	importNDJSON = "ndjson"
+
+	maxImportRows     = 10000
+	maxImportLineSize = 1 << 20
+)
+
+// importColumns are the CSV columns, named as the fields of CreateRequest.
+var importColumns = []string{"title", "description", "status", "priority", "project_id", "parent_id", "due_at", "reminder_at", "recurrence", "tags"}
+
+// ImportRequest holds the form fields sent along with the file.
+type ImportRequest struct {
+	// Format is detected by the extension of the file if it isn't set.
+	Format string `form:"format" validate:"omitempty,oneof=csv ndjson"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// importLine is a row of the imported file, Message is set if the row can't be read.
+type importLine struct {
+	Line    int
+	Request *CreateRequest
+	Message string
+}
+
+// @Summary		Import tasks from a file
+// @Description	Accepts a CSV file with a header row or an NDJSON file with a task per line. Columns of the CSV file
+// @Description	and fields of the JSON objects are the same as in POST /tasks, tags in CSV are separated by commas.
+// @Description	Each row is checked with the same rules as POST /tasks, the valid rows are created in one transaction
+// @Description	and the rest are reported along with their line numbers. Nothing is created in dry run.
+// @Tags			tasks
+// @Accept			multipart/form-data
+// @Param			file			formData	file	true	"CSV or NDJSON file, up to 10000 rows"
+// @Param			format			formData	string	false	"File format, detected by the extension (.csv, .ndjson or .jsonl) by default"	Enums(csv, ndjson)
+// @Param			dry_run			formData	bool	false	"Check the rows without creating tasks"
+// @Param			Idempotency-Key	header		string	false	"Unique key of the request, up to 255 characters"
+// @Success		200				{object}	models.ImportReport
+// @Failure		400				{object}	response.Response	"invalid file or form fields"
+// @Failure		409				{object}	response.Response	"project or parent task has been deleted during the import, request with the same Idempotency-Key is being processed"
+// @Failure		422				{object}	response.Response	"Idempotency-Key has already been used for another request"
+// @Failure		500				{object}	response.Response	"internal server error"
+// @Router			/tasks/import [post]
+func (tc *TaskController) Import(c *fiber.Ctx) error {
+	const op = "controller.tasks.Import"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	req := &ImportRequest{}
+	if err := c.BodyParser(req); err != nil {
+		log.Error("failed to parse form", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid form fields")
+	}
+	req.Format = strings.ToLower(req.Format)
+	if err := tc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "form fields validation failed")
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		log.Error("failed to get file", sl.Err(err))
+		return response.ErrorBadRequest(c, "field 'file' is required")
+	}
+	if req.Format == "" {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".csv":
+			req.Format = importCSV
+		case ".ndjson", ".jsonl":
+			req.Format = importNDJSON
+		default:
+			log.Error("unknown file format", slog.String("filename", header.Filename))
+			return response.ErrorBadRequest(c, "unknown file format, set format to csv or ndjson")
+		}
+	}
+	log.Info("request received", slog.String("filename", header.Filename), slog.Any("data", req))
+
+	file, err := header.Open()
+	if err != nil {
+		log.Error("failed to open file", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	defer file.Close()
+	var lines []*importLine
+	if req.Format == importCSV {
+		lines, err = readCSV(file)
+	} else {
+		lines, err = readNDJSON(file)
+	}
+	if err != nil {
+		log.Error("failed to read file", sl.Err(err))
+		return response.ErrorBadRequest(c, err.Error())
+	}
+	if len(lines) == 0 {
+		log.Error("file has no rows")
+		return response.ErrorBadRequest(c, "file has no rows")
+	}
+
+	rows := make([]*models.ImportRow, 0, len(lines))
+	var rejected []*models.RejectedRow
+	for _, line := range lines {
+		if line.Message == "" {
+			line.Request.normalize()
+			// statuses are checked against the ones loaded once for the request by the statuses middleware
+			if err = tc.validator.StructCtx(c.UserContext(), line.Request); err != nil {
+				var validateErr validator.ValidationErrors
+				if errors.As(err, &validateErr) {
+					line.Message = response.ValidationError(validateErr)
+				} else {
+					line.Message = "row validation failed"
+				}
+			}
+		}
+		if line.Message != "" {
+			rejected = append(rejected, &models.RejectedRow{Line: line.Line, Message: line.Message})
+			continue
+		}
+		rows = append(rows, &models.ImportRow{Line: line.Line, Task: line.Request.task()})
+	}
+
+	report, err := tc.uc.ImportTasks(c.UserContext(), rows, req.DryRun)
+	if errors.Is(err, service.ErrRelatedNotFound) {
+		log.Error("project or parent task has been deleted during the import", sl.Err(err))
+		return response.ErrorConflict(c, "project or parent task has been deleted during the import, try again")
+	} else if err != nil {
+		log.Error("failed to import tasks", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	report.Rejected = append(report.Rejected, rejected...)
+	slices.SortFunc(report.Rejected, func(a, b *models.RejectedRow) int {
+		return a.Line - b.Line
+	})
+	log.Info("tasks imported", slog.Bool("dry_run", req.DryRun),
+		slog.Int("accepted", len(report.Accepted)), slog.Int("rejected", len(report.Rejected)))
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// readCSV reads the rows after the header. An error is returned if the file can't be read at all,
+// rows that can't be converted to the request get a message instead.
+func readCSV(r io.Reader) ([]*importLine, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	// spreadsheets often save CSV with the byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(importColumns, name) {
+			return nil, fmt.Errorf("unknown column '%s'", name)
+		}
+		if slices.Contains(columns[:i], name) {
+			return nil, fmt.Errorf("duplicate column '%s'", name)
+		}
+		columns[i] = name
+	}
+	if !slices.Contains(columns, "title") {
+		return nil, errors.New("column 'title' is required")
+	}
+
+	var lines []*importLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			lines = append(lines, &importLine{Line: parseErr.StartLine, Message: fmt.Sprintf("row must have %d fields", len(columns))})
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		} else {
+			line, _ := reader.FieldPos(0)
+			req, msg := csvRequest(columns, record)
+			lines = append(lines, &importLine{Line: line, Request: req, Message: msg})
+		}
+		if len(lines) > maxImportRows {
+			return nil, fmt.Errorf("file must have at most %d rows", maxImportRows)
+		}
+	}
+	return lines, nil
+}
+
+// csvRequest converts the record to the request, empty cells are treated as missing fields.
+func csvRequest(columns []string, record []string) (*CreateRequest, string) {
+	req := &CreateRequest{}
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		switch columns[i] {
+		case "title":
+			req.Title = value
+		case "description":
+			req.Description = value
+		case "status":
+			req.Status = value
+		case "priority":
+			req.Priority = value
+		case "project_id", "parent_id":
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Sprintf("field '%s' must be an integer", columns[i])
+			}
+			if columns[i] == "project_id" {
+				req.ProjectID = &id
+			} else {
+				req.ParentID = &id
+			}
+		case "due_at":
+			req.DueAt = value
+		case "reminder_at":
+			req.ReminderAt = value
+		case "recurrence":
+			req.Recurrence = value
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					req.Tags = append(req.Tags, tag)
+				}
+			}
+		}
+	}
+	return req, ""
+}
+
+// readNDJSON reads a JSON object of the request from each non-empty line.
+func readNDJSON(r io.Reader) ([]*importLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	var lines []*importLine
+	for n := 1; scanner.Scan(); n++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if n == 1 {
+			text = bytes.TrimPrefix(text, []byte("\ufeff"))
+		}
+		if len(text) == 0 {
+			continue
+		}
+		req := &CreateRequest{}
+		if err := json.Unmarshal(text, req); err != nil {
+			lines = append(lines, &importLine{Line: n, Message: "invalid JSON"})
+		} else {
+			lines = append(lines, &importLine{Line: n, Request: req})
+		}
+		if len(lines) > maxImportRows {
+			return nil, fmt.Errorf("file must have at most %d rows", maxImportRows)
+		}
+	}
+	if err := scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return nil, fmt.Errorf("lines must be at most %d bytes long", maxImportLineSize)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return lines, nil
+}
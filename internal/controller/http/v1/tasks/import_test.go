@@ -0,0 +1,123 @@
+package tasks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	projectID := 3
+	tests := []struct {
+		name    string
+		file    string
+		want    []*importLine
+		wantErr bool
+	}{
+		{
+			name: "empty file",
+		},
+		{
+			name: "byte order mark and line numbers",
+			file: "\ufeffTitle,project_id,tags\nBuy milk,3,\"home, shop\"\n\nCall mom,,\n",
+			want: []*importLine{
+				{Line: 2, Request: &CreateRequest{Title: "Buy milk", ProjectID: &projectID, Tags: []string{"home", "shop"}}},
+				{Line: 4, Request: &CreateRequest{Title: "Call mom"}},
+			},
+		},
+		{
+			name: "quoted field spans lines",
+			file: "title,description\nBuy milk,\"2 liters\nlactose free\"\nCall mom,\n",
+			want: []*importLine{
+				{Line: 2, Request: &CreateRequest{Title: "Buy milk", Description: "2 liters\nlactose free"}},
+				{Line: 4, Request: &CreateRequest{Title: "Call mom"}},
+			},
+		},
+		{
+			name: "rows that can't be converted",
+			file: "title,project_id\nBuy milk\nCall mom,first\nWalk,\n",
+			want: []*importLine{
+				{Line: 2, Message: "row must have 2 fields"},
+				{Line: 3, Message: "field 'project_id' must be an integer"},
+				{Line: 4, Request: &CreateRequest{Title: "Walk"}},
+			},
+		},
+		{
+			name:    "unknown column",
+			file:    "title,done\nBuy milk,true\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate column",
+			file:    "title,Title\nBuy milk,Buy milk\n",
+			wantErr: true,
+		},
+		{
+			name:    "no title column",
+			file:    "description\n2 liters\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			file:    "title\n\"Buy milk\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCSV(strings.NewReader(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []*importLine
+		wantErr bool
+	}{
+		{
+			name: "empty file",
+		},
+		{
+			name: "byte order mark and blank lines",
+			file: "\ufeff{\"title\":\"Buy milk\"}\n\n  \n{\"title\":\"Call mom\",\"status\":\"new\"}",
+			want: []*importLine{
+				{Line: 1, Request: &CreateRequest{Title: "Buy milk"}},
+				{Line: 4, Request: &CreateRequest{Title: "Call mom", Status: "new"}},
+			},
+		},
+		{
+			name: "invalid JSON",
+			file: "{\"title\":\"Buy milk\"}\n{\"title\":\n{\"title\":3}\n",
+			want: []*importLine{
+				{Line: 1, Request: &CreateRequest{Title: "Buy milk"}},
+				{Line: 2, Message: "invalid JSON"},
+				{Line: 3, Message: "invalid JSON"},
+			},
+		},
+		{
+			name:    "line is too long",
+			file:    strings.Repeat(" ", maxImportLineSize+1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readNDJSON(strings.NewReader(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readNDJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readNDJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+type MoveRequest struct {
+	// Status is the target column, the current status of the task is kept if it's empty.
+	Status string `json:"status,omitempty" validate:"omitempty,status"`
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+	// AfterID and BeforeID are the tasks the moved task is put between.
+	AfterID  *int `json:"after_id,omitempty" validate:"omitnil,min=1"`
+	BeforeID *int `json:"before_id,omitempty" validate:"omitnil,min=1"`
+}
+
+// @Summary		Move task
+// @Description	Puts the task between after_id and before_id tasks of the target status column,
+// @Description	one of them is enough. Without neighbours the task goes to the end of the column.
+// @Description	Changing the status follows the same rules as the transitions endpoint.
+// @Tags			tasks
+// @Param			id			path		int					true	"Task ID"
+// @Param			If-Match	header		string				false	"ETag of the task the move is based on"
+// @Param			Move		body		MoveRequest			true	"Target column and neighbours"
+// @Success		200			{object}	models.Task
+// @Header			200			{string}	ETag				"New version of the task"
+// @Failure		400			{object}	response.Response	"invalid request body or task ID"
+// @Failure		404			{object}	response.Response	"task not found"
+// @Failure		409			{object}	response.Response	"task has already been updated, try again"
+// @Failure		412			{object}	response.Response	"task version doesn't match If-Match"
+// @Failure		422			{object}	response.Response	"neighbours are not in the column or status change is not allowed"
+// @Failure		500			{object}	response.Response	"internal server error"
+// @Router			/tasks/{id}/move [post]
+func (tc *TaskController) Move(c *fiber.Ctx) error {
+	const op = "controller.tasks.Move"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	req := &MoveRequest{}
+	if err = c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.Status = strings.ToLower(req.Status)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err = tc.validator.StructCtx(c.UserContext(), req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		log.Error("failed to parse If-Match header", sl.Err(err))
+		return ifMatchError(c, err)
+	}
+	log.Info("request received", slog.Any("data", req), slog.Int("if_match", version))
+
+	task, err := tc.uc.MoveTask(c.UserContext(), &models.TaskMove{
+		ID:           id,
+		Version:      version,
+		Status:       req.Status,
+		StatusReason: req.Reason,
+		AfterID:      req.AfterID,
+		BeforeID:     req.BeforeID,
+	})
+	if err != nil {
+		status, msg := taskError(err)
+		log.Error("failed to move task", sl.Err(err), slog.Int("status", status))
+		return response.Error(c, status, msg)
+	}
+	log.Info("task moved", slog.Any("data", task))
+
+	setETag(c, task)
+	return c.Status(fiber.StatusOK).JSON(task)
+}
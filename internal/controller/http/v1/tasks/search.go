@@ -0,0 +1,73 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strings"
+)
+
+type SearchRequest struct {
+	Q      string `query:"q" validate:"required,max=500"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int    `query:"offset" validate:"omitempty,min=0"`
+}
+
+// @Summary		Search tasks
+// @Description	Full-text search over titles and descriptions of the tasks that are not in the trash.
+// @Description	The query supports quoted phrases, "or" between alternatives and "-" before excluded words.
+// @Description	Results are ordered by rank, matches in the title rank higher. Matched words in highlights
+// @Description	are wrapped in <mark> tags, the text of highlights is HTML-escaped.
+// @Tags			tasks
+// @Param			q		query		string	true	"Search query"	maxlength(500)
+// @Param			limit	query		int		false	"Page size, 20 by default"	minimum(1)	maximum(100)
+// @Param			offset	query		int		false	"Number of results to skip"	minimum(0)
+// @Success		200		{object}	models.SearchPage
+// @Failure		400		{object}	response.Response	"invalid query parameters"
+// @Failure		500		{object}	response.Response	"internal server error"
+// @Router			/tasks/search [get]
+func (tc *TaskController) Search(c *fiber.Ctx) error {
+	const op = "controller.tasks.Search"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	req := &SearchRequest{}
+	if err := c.QueryParser(req); err != nil {
+		log.Error("failed to parse query params", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid query parameters")
+	}
+	req.Q = strings.TrimSpace(req.Q)
+	if err := tc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "query parameters validation failed")
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	log.Info("request received", slog.Any("data", req))
+
+	page, err := tc.uc.SearchTasks(c.UserContext(), &models.SearchQuery{
+		Query:  req.Q,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		log.Error("failed to search tasks", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("tasks found", slog.Int("count", len(page.Results)), slog.Int("total", page.Total))
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
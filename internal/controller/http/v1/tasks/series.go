@@ -0,0 +1,169 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// SeriesRequest changes the occurrences of the series that are not done yet, absent fields are left unchanged.
+type SeriesRequest struct {
+	Title       *string `json:"title,omitempty" validate:"omitnil,min=1"`
+	Description *string `json:"description,omitempty"`
+	Priority    *string `json:"priority,omitempty" validate:"omitnil,oneof=low medium high urgent"`
+	Recurrence  *string `json:"recurrence,omitempty" validate:"omitnil,min=1,max=500" example:"FREQ=WEEKLY;BYDAY=SU"`
+}
+
+// @Summary		Get occurrences of the recurring task
+// @Description	The series id is the id of the first task of the series, it is returned as series_id of every occurrence.
+// @Tags			tasks
+// @Param			id	path		int	true	"Series ID"
+// @Success		200	{object}	[]models.Task
+// @Failure		400	{object}	response.Response	"invalid series ID"
+// @Failure		404	{object}	response.Response	"series not found"
+// @Failure		500	{object}	response.Response	"internal server error"
+// @Router			/series/{id} [get]
+func (tc *TaskController) Series(c *fiber.Ctx) error {
+	const op = "controller.tasks.Series"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("invalid series id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid series ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	tasks, err := tc.uc.GetSeries(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("series not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to get series", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("series received", slog.Int("count", len(tasks)))
+
+	return c.Status(fiber.StatusOK).JSON(tasks)
+}
+
+// @Summary		Update recurring task
+// @Description	Changes all occurrences of the series that are not done yet and returns them,
+// @Description	done occurrences are kept as they were. A recurrence rule without DTSTART
+// @Description	starts from the due date of the last occurrence.
+// @Tags			tasks
+// @Param			id		path		int				true	"Series ID"
+// @Param			Series	body		SeriesRequest	true	"Fields to change"
+// @Success		200		{object}	[]models.Task
+// @Failure		400		{object}	response.Response	"invalid request body, series ID or recurrence rule"
+// @Failure		404		{object}	response.Response	"series not found"
+// @Failure		500		{object}	response.Response	"internal server error"
+// @Router			/series/{id} [patch]
+func (tc *TaskController) UpdateSeries(c *fiber.Ctx) error {
+	const op = "controller.tasks.UpdateSeries"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("invalid series id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid series ID")
+	}
+	req := &SeriesRequest{}
+	if err = c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	if req.Priority != nil {
+		priority := strings.ToLower(*req.Priority)
+		req.Priority = &priority
+	}
+	if req.Recurrence != nil {
+		recurrence := strings.TrimSpace(*req.Recurrence)
+		req.Recurrence = &recurrence
+	}
+	if err = tc.validator.StructCtx(c.UserContext(), req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+	log.Info("request received", slog.Int("id", id), slog.Any("data", req))
+
+	patch := &models.SeriesPatch{
+		Title:       req.Title,
+		Description: req.Description,
+		Recurrence:  req.Recurrence,
+	}
+	if req.Priority != nil {
+		priority := parsePriority(*req.Priority)
+		patch.Priority = &priority
+	}
+	tasks, err := tc.uc.UpdateSeries(c.UserContext(), id, patch)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("series not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if errors.Is(err, service.ErrInvalidRecurrence) {
+		log.Error("invalid recurrence rule", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid recurrence rule")
+	} else if err != nil {
+		log.Error("failed to update series", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("series updated", slog.Int("count", len(tasks)))
+
+	return c.Status(fiber.StatusOK).JSON(tasks)
+}
+
+// @Summary		Stop recurring task
+// @Description	Removes the recurrence from all occurrences of the series, so no more occurrences are created.
+// @Description	The occurrences themselves are kept.
+// @Tags			tasks
+// @Param			id	path		int	true	"Series ID"
+// @Success		200	{object}	[]models.Task
+// @Failure		400	{object}	response.Response	"invalid series ID"
+// @Failure		404	{object}	response.Response	"series not found"
+// @Failure		500	{object}	response.Response	"internal server error"
+// @Router			/series/{id} [delete]
+func (tc *TaskController) StopSeries(c *fiber.Ctx) error {
+	const op = "controller.tasks.StopSeries"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("invalid series id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid series ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	tasks, err := tc.uc.StopSeries(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("series not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to stop series", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("series stopped", slog.Int("count", len(tasks)))
+
+	return c.Status(fiber.StatusOK).JSON(tasks)
+}
@@ -0,0 +1,94 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+)
+
+// @Summary	Get subtasks of the task
+// @Tags		tasks
+// @Param		id	path		int	true	"Task ID"
+// @Success	200	{object}	[]models.Task
+// @Failure	400	{object}	response.Response	"invalid task ID"
+// @Failure	404	{object}	response.Response	"task not found"
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/tasks/{id}/children [get]
+func (tc *TaskController) Subtasks(c *fiber.Ctx) error {
+	const op = "controller.tasks.Subtasks"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	tasks, err := tc.uc.GetSubtasks(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to get subtasks", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("subtasks received", slog.Int("count", len(tasks)))
+
+	return c.Status(fiber.StatusOK).JSON(tasks)
+}
+
+// @Summary		Get the task with all of its subtasks
+// @Description	Returns the task followed by its subtasks at any depth in depth-first order,
+// @Description	the tree can be built using parent_id. Subtasks in the trash are skipped along with their subtasks.
+// @Tags			tasks
+// @Param			id	path		int	true	"Task ID"
+// @Success		200	{object}	[]models.Task
+// @Failure		400	{object}	response.Response	"invalid task ID"
+// @Failure		404	{object}	response.Response	"task not found"
+// @Failure		500	{object}	response.Response	"internal server error"
+// @Router			/tasks/{id}/subtree [get]
+func (tc *TaskController) Subtree(c *fiber.Ctx) error {
+	const op = "controller.tasks.Subtree"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	tasks, err := tc.uc.GetSubtree(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
+		log.Error("failed to get subtree", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("subtree received", slog.Int("count", len(tasks)))
+
+	return c.Status(fiber.StatusOK).JSON(tasks)
+}
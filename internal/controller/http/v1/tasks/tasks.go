@@ -1,7 +1,9 @@
 package tasks
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
@@ -10,15 +12,39 @@ import (
 	"github.com/igorgrichanov/toDoList/pkg/api/response"
 	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
 	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Tasker interface {
 	Create(c *fiber.Ctx) error
 	List(c *fiber.Ctx) error
+	Trash(c *fiber.Ctx) error
+	Search(c *fiber.Ctx) error
+	Bulk(c *fiber.Ctx) error
+	Import(c *fiber.Ctx) error
+	Get(c *fiber.Ctx) error
 	Update(c *fiber.Ctx) error
+	Patch(c *fiber.Ctx) error
 	Delete(c *fiber.Ctx) error
+	Restore(c *fiber.Ctx) error
+	Purge(c *fiber.Ctx) error
+	ListInProject(c *fiber.Ctx) error
+	CreateInProject(c *fiber.Ctx) error
+	Subtasks(c *fiber.Ctx) error
+	Subtree(c *fiber.Ctx) error
+	History(c *fiber.Ctx) error
+	Revert(c *fiber.Ctx) error
+	Undelete(c *fiber.Ctx) error
+	AddDependency(c *fiber.Ctx) error
+	RemoveDependency(c *fiber.Ctx) error
+	Transition(c *fiber.Ctx) error
+	Move(c *fiber.Ctx) error
+	Series(c *fiber.Ctx) error
+	UpdateSeries(c *fiber.Ctx) error
+	StopSeries(c *fiber.Ctx) error
 }
 
 type TaskController struct {
@@ -32,18 +58,30 @@ func NewTaskController(log *slog.Logger, uc service.Tasks, v *validator.Validate
 }
 
 type CreateRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description,omitempty"`
-	Status      string `json:"status,omitempty" validate:"omitempty,oneof=new in_progress done"`
+	Title       string   `json:"title" validate:"required"`
+	Description string   `json:"description,omitempty"`
+	Status      string   `json:"status,omitempty" validate:"omitempty,status"`
+	Priority    string   `json:"priority,omitempty" validate:"omitempty,oneof=low medium high urgent"`
+	ProjectID   *int     `json:"project_id,omitempty" validate:"omitnil,min=1"`
+	ParentID    *int     `json:"parent_id,omitempty" validate:"omitnil,min=1"`
+	DueAt       string   `json:"due_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" example:"2025-05-01T18:00:00Z"`
+	ReminderAt  string   `json:"reminder_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" example:"2025-05-01T09:00:00Z"`
+	Recurrence  string   `json:"recurrence,omitempty" validate:"max=500" example:"FREQ=WEEKLY;BYDAY=SA"`
+	Tags        []string `json:"tags,omitempty" validate:"max=20,dive,required,max=50"`
 }
 
-// @Summary	Create a new task
-// @Tags		tasks
-// @Param		Task	body		CreateRequest	true	"Specify task title. Description and status are optional"
-// @Success	201		{object}	models.Task
-// @Failure	400		{object}	response.Response	"invalid request body"
-// @Failure	500		{object}	response.Response	"internal server error"
-// @Router		/tasks [post]
+// @Summary		Create a new task
+// @Description	Requests with an Idempotency-Key header may be safely retried: the response to the first
+// @Description	request with the key is sent again with the Idempotent-Replayed header.
+// @Tags			tasks
+// @Param			Idempotency-Key	header		string			false	"Unique key of the request, up to 255 characters"
+// @Param			Task			body		CreateRequest	true	"Specify task title. Description and status are optional"
+// @Success		201				{object}	models.Task
+// @Failure		400				{object}	response.Response	"invalid request body"
+// @Failure		409				{object}	response.Response	"request with the same Idempotency-Key is being processed"
+// @Failure		422				{object}	response.Response	"Idempotency-Key has already been used for another request, project or parent task not found"
+// @Failure		500				{object}	response.Response	"internal server error"
+// @Router			/tasks [post]
 func (tc *TaskController) Create(c *fiber.Ctx) error {
 	const op = "controller.tasks.Create"
 	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
@@ -51,14 +89,46 @@ func (tc *TaskController) Create(c *fiber.Ctx) error {
 		slog.String("op", op),
 		slog.String("request_id", requestID),
 	)
+	return tc.create(c, log, nil)
+}
+
+// @Summary		Create a new task in the project
+// @Description	Same as POST /tasks, project_id of the body is ignored.
+// @Tags			tasks
+// @Param			id				path		int				true	"Project ID"
+// @Param			Idempotency-Key	header		string			false	"Unique key of the request, up to 255 characters"
+// @Param			Task			body		CreateRequest	true	"Specify task title. Description and status are optional"
+// @Success		201				{object}	models.Task
+// @Failure		400				{object}	response.Response	"invalid request body or project ID"
+// @Failure		404				{object}	response.Response	"project not found"
+// @Failure		409				{object}	response.Response	"request with the same Idempotency-Key is being processed"
+// @Failure		422				{object}	response.Response	"Idempotency-Key has already been used for another request"
+// @Failure		500				{object}	response.Response	"internal server error"
+// @Router			/projects/{id}/tasks [post]
+func (tc *TaskController) CreateInProject(c *fiber.Ctx) error {
+	const op = "controller.tasks.CreateInProject"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	projectID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || projectID < 1 {
+		log.Error("invalid project id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid project ID")
+	}
+	return tc.create(c, log, &projectID)
+}
 
+// create serves both POST /tasks and POST /projects/:id/tasks, projectID overrides the one from the body.
+func (tc *TaskController) create(c *fiber.Ctx, log *slog.Logger, projectID *int) error {
 	req := &CreateRequest{}
 	if err := c.BodyParser(req); err != nil {
 		log.Error("failed to parse request body", sl.Err(err))
 		return response.ErrorBadRequest(c, "invalid request body")
 	}
-	req.Status = strings.ToLower(req.Status)
-	if err := tc.validator.Struct(req); err != nil {
+	req.normalize()
+	if err := tc.validator.StructCtx(c.UserContext(), req); err != nil {
 		log.Error("validation failed", sl.Err(err))
 		var validateErr validator.ValidationErrors
 		if errors.As(err, &validateErr) {
@@ -68,27 +138,106 @@ func (tc *TaskController) Create(c *fiber.Ctx) error {
 		return response.ErrorBadRequest(c, "request body validation failed")
 
 	}
+	if projectID != nil {
+		req.ProjectID = projectID
+	}
 	log.Info("request received", slog.Any("data", req))
 
-	task, err := tc.uc.CreateTask(c.UserContext(), &models.Task{
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      req.Status,
-	})
-	if err != nil {
+	task, err := tc.uc.CreateTask(c.UserContext(), req.task())
+	if errors.Is(err, service.ErrRelatedNotFound) && projectID != nil {
+		log.Error("project not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if errors.Is(err, service.ErrRelatedNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return response.ErrorUnprocessableEntity(c, "project not found")
+	} else if errors.Is(err, service.ErrParentNotFound) {
+		log.Error("parent task not found", sl.Err(err))
+		return response.ErrorUnprocessableEntity(c, "parent task not found")
+	} else if errors.Is(err, service.ErrInvalidRecurrence) {
+		log.Error("invalid recurrence rule", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid recurrence rule")
+	} else if err != nil {
 		log.Error("failed to create task", sl.Err(err))
 		return response.ErrorInternal(c)
 	}
 	log.Info("task created", slog.Any("data", task))
 
+	setETag(c, task)
 	return c.Status(fiber.StatusCreated).JSON(task)
 }
 
-// @Summary	Get list of existing tasks
-// @Tags		tasks
-// @Success	200	{object}	[]models.Task
-// @Failure	500	{object}	response.Response	"internal server error"
-// @Router		/tasks [get]
+func (r *CreateRequest) normalize() {
+	r.Status = strings.ToLower(r.Status)
+	r.Priority = strings.ToLower(r.Priority)
+	r.Tags = normalizeTags(r.Tags)
+}
+
+// task converts the validated request to the new task.
+func (r *CreateRequest) task() *models.Task {
+	return &models.Task{
+		Title:       r.Title,
+		Description: r.Description,
+		Status:      r.Status,
+		Priority:    parsePriority(r.Priority),
+		ProjectID:   r.ProjectID,
+		ParentID:    r.ParentID,
+		DueAt:       parseTime(r.DueAt),
+		ReminderAt:  parseTime(r.ReminderAt),
+		Recurrence:  strings.TrimSpace(r.Recurrence),
+		Tags:        r.Tags,
+	}
+}
+
+const defaultListLimit = 20
+
+// sortableColumns lists the values accepted by the sort query parameter.
+var sortableColumns = []string{"id", "title", "status", "priority", "position", "created_at", "updated_at"}
+
+type ListRequest struct {
+	Limit         int      `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset        int      `query:"offset" validate:"omitempty,min=0"`
+	After         string   `query:"after" validate:"excluded_with=Offset"`
+	ProjectID     int      `query:"project_id" validate:"omitempty,min=1"`
+	Status        []string `query:"status" validate:"dive,status"`
+	Priority      []string `query:"priority" validate:"dive,oneof=low medium high urgent"`
+	Tag           []string `query:"tag" validate:"dive,max=50"`
+	CreatedAfter  string   `query:"created_after" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedBefore string   `query:"created_before" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	UpdatedAfter  string   `query:"updated_after" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	UpdatedBefore string   `query:"updated_before" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	DueAfter      string   `query:"due_after" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	DueBefore     string   `query:"due_before" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Overdue       bool     `query:"overdue"`
+	Title         string   `query:"title"`
+	Sort          string   `query:"sort"`
+}
+
+// @Summary		Get list of existing tasks
+// @Description	Tasks are returned page by page. Use either offset or the next_cursor value
+// @Description	of the previous page passed as after to get the next page.
+// @Description	Time ranges include the lower bound and exclude the upper one.
+// @Tags			tasks
+// @Param			limit			query		int			false	"Page size, 20 by default"	minimum(1)	maximum(100)
+// @Param			offset			query		int			false	"Number of tasks to skip"	minimum(0)
+// @Param			after			query		string		false	"Cursor returned as next_cursor of the previous page"
+// @Param			project_id		query		int			false	"Project ID"
+// @Param			status			query		[]string	false	"Task statuses, may be repeated or comma-separated"	collectionFormat(multi)
+// @Param			priority		query		[]string	false	"Task priorities, may be repeated or comma-separated"	collectionFormat(multi)
+// @Param			tag				query		[]string	false	"Tasks having any of the tags, may be repeated or comma-separated"	collectionFormat(multi)
+// @Param			created_after	query		string		false	"RFC 3339 timestamp"
+// @Param			created_before	query		string		false	"RFC 3339 timestamp"
+// @Param			updated_after	query		string		false	"RFC 3339 timestamp"
+// @Param			updated_before	query		string		false	"RFC 3339 timestamp"
+// @Param			due_after		query		string		false	"RFC 3339 timestamp"
+// @Param			due_before		query		string		false	"RFC 3339 timestamp"
+// @Param			overdue			query		bool		false	"Only unfinished tasks with due date in the past"
+// @Param			title			query		string		false	"Case-insensitive substring of the title"
+// @Param			sort			query		string		false	"Comma-separated columns to sort by, prefixed with - for descending order, e.g. -updated_at,title. Sortable columns: id, title, status, priority, position, created_at, updated_at"
+// @Success		200				{object}	models.TaskPage
+// @Failure		400				{object}	response.Response	"invalid query parameters"
+// @Failure		404				{object}	response.Response	"project not found"
+// @Failure		500				{object}	response.Response	"internal server error"
+// @Router			/tasks [get]
 func (tc *TaskController) List(c *fiber.Ctx) error {
 	const op = "controller.tasks.List"
 	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
@@ -96,32 +245,317 @@ func (tc *TaskController) List(c *fiber.Ctx) error {
 		slog.String("op", op),
 		slog.String("request_id", requestID),
 	)
-	log.Info("request received")
+	return tc.list(c, log, false, nil)
+}
 
-	tasks, err := tc.uc.ListTasks(c.UserContext())
-	if err != nil {
+// @Summary		Get list of deleted tasks
+// @Description	Accepts the same parameters as GET /tasks.
+// @Tags			tasks
+// @Param			limit			query		int			false	"Page size, 20 by default"	minimum(1)	maximum(100)
+// @Param			offset			query		int			false	"Number of tasks to skip"	minimum(0)
+// @Param			after			query		string		false	"Cursor returned as next_cursor of the previous page"
+// @Param			project_id		query		int			false	"Project ID"
+// @Param			status			query		[]string	false	"Task statuses, may be repeated or comma-separated"	collectionFormat(multi)
+// @Param			priority		query		[]string	false	"Task priorities, may be repeated or comma-separated"	collectionFormat(multi)
+// @Param			tag				query		[]string	false	"Tasks having any of the tags, may be repeated or comma-separated"	collectionFormat(multi)
+// @Param			created_after	query		string		false	"RFC 3339 timestamp"
+// @Param			created_before	query		string		false	"RFC 3339 timestamp"
+// @Param			updated_after	query		string		false	"RFC 3339 timestamp"
+// @Param			updated_before	query		string		false	"RFC 3339 timestamp"
+// @Param			due_after		query		string		false	"RFC 3339 timestamp"
+// @Param			due_before		query		string		false	"RFC 3339 timestamp"
+// @Param			overdue			query		bool		false	"Only unfinished tasks with due date in the past"
+// @Param			title			query		string		false	"Case-insensitive substring of the title"
+// @Param			sort			query		string		false	"Comma-separated columns to sort by, prefixed with - for descending order"
+// @Success		200				{object}	models.TaskPage
+// @Failure		400				{object}	response.Response	"invalid query parameters"
+// @Failure		500				{object}	response.Response	"internal server error"
+// @Router			/tasks/trash [get]
+func (tc *TaskController) Trash(c *fiber.Ctx) error {
+	const op = "controller.tasks.Trash"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	return tc.list(c, log, true, nil)
+}
+
+// @Summary		Get list of tasks in the project
+// @Description	Accepts the same parameters as GET /tasks, project_id is taken from the path.
+// @Tags			tasks
+// @Param			id				path		int			true	"Project ID"
+// @Param			limit			query		int			false	"Page size, 20 by default"	minimum(1)	maximum(100)
+// @Param			offset			query		int			false	"Number of tasks to skip"	minimum(0)
+// @Param			after			query		string		false	"Cursor returned as next_cursor of the previous page"
+// @Param			status			query		[]string	false	"Task statuses, may be repeated or comma-separated"	collectionFormat(multi)
+// @Param			sort			query		string		false	"Comma-separated columns to sort by, prefixed with - for descending order"
+// @Success		200				{object}	models.TaskPage
+// @Failure		400				{object}	response.Response	"invalid query parameters or project ID"
+// @Failure		404				{object}	response.Response	"project not found"
+// @Failure		500				{object}	response.Response	"internal server error"
+// @Router			/projects/{id}/tasks [get]
+func (tc *TaskController) ListInProject(c *fiber.Ctx) error {
+	const op = "controller.tasks.ListInProject"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	projectID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || projectID < 1 {
+		log.Error("invalid project id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid project ID")
+	}
+	return tc.list(c, log, false, &projectID)
+}
+
+// list serves the list of tasks, the trash and tasks of a project, projectID overrides the query parameter.
+func (tc *TaskController) list(c *fiber.Ctx, log *slog.Logger, trashed bool, projectID *int) error {
+	req := &ListRequest{}
+	if err := c.QueryParser(req); err != nil {
+		log.Error("failed to parse query params", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid query parameters")
+	}
+	req.Status = splitValues(req.Status)
+	req.Priority = splitValues(req.Priority)
+	req.Tag = normalizeTags(splitValues(req.Tag))
+	if err := tc.validator.StructCtx(c.UserContext(), req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "query parameters validation failed")
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	filter, msg := req.filter()
+	if msg != "" {
+		log.Error("invalid filter", slog.String("reason", msg))
+		return response.ErrorBadRequest(c, msg)
+	}
+	filter.Trashed = trashed
+	if projectID != nil {
+		filter.ProjectID = projectID
+	}
+	sort, msg := parseSort(req.Sort)
+	if msg != "" {
+		log.Error("invalid sort", slog.String("reason", msg))
+		return response.ErrorBadRequest(c, msg)
+	}
+	log.Info("request received", slog.Any("data", req))
+
+	page, err := tc.uc.ListTasks(c.UserContext(), filter, &models.Page{
+		Limit:  req.Limit,
+		Offset: req.Offset,
+		After:  req.After,
+		Sort:   sort,
+	})
+	if errors.Is(err, service.ErrInvalidInput) {
+		log.Error("invalid cursor", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid cursor")
+	} else if errors.Is(err, service.ErrRelatedNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if err != nil {
 		log.Error("failed to list tasks", sl.Err(err))
 		return response.ErrorInternal(c)
 	}
 
-	log.Info("tasks received", slog.Any("data", tasks))
-	return c.Status(fiber.StatusOK).JSON(tasks)
+	log.Info("tasks received", slog.Int("count", len(page.Tasks)), slog.Int("total", page.Total))
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// filter converts validated query parameters to models.ListFilter.
+// A non-empty message is returned if the parameters are inconsistent.
+func (r *ListRequest) filter() (*models.ListFilter, string) {
+	filter := &models.ListFilter{
+		Statuses:      r.Status,
+		Priorities:    make([]models.Priority, 0, len(r.Priority)),
+		Tags:          r.Tag,
+		CreatedAfter:  parseTime(r.CreatedAfter),
+		CreatedBefore: parseTime(r.CreatedBefore),
+		UpdatedAfter:  parseTime(r.UpdatedAfter),
+		UpdatedBefore: parseTime(r.UpdatedBefore),
+		DueAfter:      parseTime(r.DueAfter),
+		DueBefore:     parseTime(r.DueBefore),
+		Overdue:       r.Overdue,
+		Title:         r.Title,
+	}
+	if r.ProjectID != 0 {
+		filter.ProjectID = &r.ProjectID
+	}
+	for _, p := range r.Priority {
+		filter.Priorities = append(filter.Priorities, parsePriority(p))
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return nil, "created_after must be earlier than created_before"
+	}
+	if filter.UpdatedAfter != nil && filter.UpdatedBefore != nil && !filter.UpdatedAfter.Before(*filter.UpdatedBefore) {
+		return nil, "updated_after must be earlier than updated_before"
+	}
+	if filter.DueAfter != nil && filter.DueBefore != nil && !filter.DueAfter.Before(*filter.DueBefore) {
+		return nil, "due_after must be earlier than due_before"
+	}
+	return filter, ""
+}
+
+// parseSort parses a value like "-updated_at,title". Ties are broken by id in the repository.
+// A non-empty message is returned if the value is invalid.
+func parseSort(s string) ([]models.SortField, string) {
+	if s == "" {
+		return nil, ""
+	}
+	parts := strings.Split(s, ",")
+	fields := make([]models.SortField, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		field := models.SortField{Column: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !slices.Contains(sortableColumns, field.Column) {
+			return nil, fmt.Sprintf("can't sort by '%s', sortable columns are [%s]", field.Column, strings.Join(sortableColumns, " "))
+		}
+		if seen[field.Column] {
+			return nil, fmt.Sprintf("column '%s' is listed in sort more than once", field.Column)
+		}
+		seen[field.Column] = true
+		fields = append(fields, field)
+	}
+	return fields, ""
+}
+
+// normalizeTags makes tag names case-insensitive before validation.
+func normalizeTags(tags []string) []string {
+	for i := range tags {
+		tags[i] = models.NormalizeTagName(tags[i])
+	}
+	return tags
+}
+
+// parsePriority converts a validated priority name, empty name means the priority is not set.
+func parsePriority(name string) models.Priority {
+	p, _ := models.ParsePriority(name)
+	return p
+}
+
+// parseTime parses a timestamp that has already passed the datetime validation.
+func parseTime(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// splitValues splits comma-separated values of a repeated query parameter
+// and lowercases them, so that both ?status=new,done and ?status=new&status=done work.
+func splitValues(values []string) []string {
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				res = append(res, strings.ToLower(part))
+			}
+		}
+	}
+	return res
+}
+
+// @Summary	Get task by ID
+// @Tags		tasks
+// @Param		id	path		int	true	"Task ID"
+// @Success	200	{object}	models.Task
+// @Header		200	{string}	ETag	"Version of the task to pass in If-Match"
+// @Failure	400	{object}	response.Response	"invalid task ID"
+// @Failure	404	{object}	response.Response	"task not found"
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/tasks/{id} [get]
+func (tc *TaskController) Get(c *fiber.Ctx) error {
+	const op = "controller.tasks.Get"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	task, err := tc.uc.GetTask(c.UserContext(), id)
+	if err != nil {
+		status, msg := taskError(err)
+		log.Error("failed to get task", sl.Err(err), slog.Int("status", status))
+		return response.Error(c, status, msg)
+	}
+	log.Info("task received", slog.Any("data", task))
+
+	setETag(c, task)
+	return c.Status(fiber.StatusOK).JSON(task)
 }
 
 type UpdateRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description,omitempty"`
-	Status      string `json:"status" validate:"oneof=new in_progress done"`
+	Title       string   `json:"title" validate:"required"`
+	Description string   `json:"description,omitempty"`
+	Status      string   `json:"status" validate:"required,status"`
+	Priority    string   `json:"priority,omitempty" validate:"omitempty,oneof=low medium high urgent"`
+	ProjectID   *int     `json:"project_id,omitempty" validate:"omitnil,min=1"`
+	ParentID    *int     `json:"parent_id,omitempty" validate:"omitnil,min=1"`
+	DueAt       string   `json:"due_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" example:"2025-05-01T18:00:00Z"`
+	ReminderAt  string   `json:"reminder_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" example:"2025-05-01T09:00:00Z"`
+	Recurrence  string   `json:"recurrence,omitempty" validate:"max=500" example:"FREQ=WEEKLY;BYDAY=SA"`
+	Tags        []string `json:"tags,omitempty" validate:"max=20,dive,required,max=50"`
+}
+
+func (r *UpdateRequest) normalize() {
+	r.Status = strings.ToLower(r.Status)
+	r.Priority = strings.ToLower(r.Priority)
+	r.Tags = normalizeTags(r.Tags)
+}
+
+// task converts the validated request to the task replacing the one with the id.
+func (r *UpdateRequest) task(id int, version int) *models.Task {
+	return &models.Task{
+		ID:          id,
+		Title:       r.Title,
+		Description: r.Description,
+		Status:      r.Status,
+		Priority:    parsePriority(r.Priority),
+		ProjectID:   r.ProjectID,
+		ParentID:    r.ParentID,
+		DueAt:       parseTime(r.DueAt),
+		ReminderAt:  parseTime(r.ReminderAt),
+		Recurrence:  strings.TrimSpace(r.Recurrence),
+		Tags:        r.Tags,
+		Version:     version,
+	}
 }
 
 // @Summary	Update task
 // @Tags		tasks
-// @Param		id		path	int				true	"Task ID"
-// @Param		Task	body	UpdateRequest	true	"Specify fields to update"
+// @Param		id			path	int				true	"Task ID"
+// @Param		If-Match	header	string			false	"ETag of the task the update is based on"
+// @Param		Task		body	UpdateRequest	true	"Specify fields to update"
 // @Success	204
+// @Header		204	{string}	ETag				"New version of the task"
 // @Failure	400	{object}	response.Response	"invalid request body or task ID"
 // @Failure	404	{object}	response.Response	"task not found"
 // @Failure	409	{object}	response.Response	"task has already been updated, try again"
+// @Failure	412	{object}	response.Response	"task version doesn't match If-Match"
+// @Failure	422	{object}	response.Response	"status change is not allowed by the workflow or by subtasks and dependencies, project or parent task not found"
 // @Failure	500	{object}	response.Response	"internal server error"
 // @Router		/tasks/{id} [put]
 func (tc *TaskController) Update(c *fiber.Ctx) error {
@@ -146,8 +580,8 @@ func (tc *TaskController) Update(c *fiber.Ctx) error {
 		log.Error("failed to parse request body", sl.Err(err))
 		return response.ErrorBadRequest(c, "invalid request body")
 	}
-	req.Status = strings.ToLower(req.Status)
-	if err = tc.validator.Struct(req); err != nil {
+	req.normalize()
+	if err = tc.validator.StructCtx(c.UserContext(), req); err != nil {
 		log.Error("validation failed", sl.Err(err))
 		var validateErr validator.ValidationErrors
 		if errors.As(err, &validateErr) {
@@ -156,35 +590,222 @@ func (tc *TaskController) Update(c *fiber.Ctx) error {
 		}
 		return response.ErrorBadRequest(c, "request body validation failed")
 	}
-	log.Info("request received", slog.Any("data", req))
 
-	err = tc.uc.UpdateTask(c.UserContext(), &models.Task{
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		log.Error("failed to parse If-Match header", sl.Err(err))
+		return ifMatchError(c, err)
+	}
+	log.Info("request received", slog.Any("data", req), slog.Int("if_match", version))
+
+	task, err := tc.uc.UpdateTask(c.UserContext(), req.task(id, version))
+	if err != nil {
+		status, msg := taskError(err)
+		log.Error("failed to update task", sl.Err(err), slog.Int("status", status))
+		return response.Error(c, status, msg)
+	}
+	log.Info("task updated", slog.Any("data", task))
+
+	setETag(c, task)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// PatchRequest is a JSON Merge Patch (RFC 7396) document. Fields that are not present
+// are left unchanged, null removes the value, which is only allowed for optional fields.
+type PatchRequest struct {
+	Title       *string  `json:"title,omitempty" validate:"omitnil,min=1"`
+	Description *string  `json:"description,omitempty"`
+	Status      *string  `json:"status,omitempty" validate:"omitnil,status"`
+	Priority    *string  `json:"priority,omitempty" validate:"omitnil,oneof=low medium high urgent"`
+	ProjectID   *int     `json:"project_id,omitempty" validate:"omitnil,min=1"`
+	ParentID    *int     `json:"parent_id,omitempty" validate:"omitnil,min=1"`
+	DueAt       *string  `json:"due_at,omitempty" validate:"omitnil,datetime=2006-01-02T15:04:05Z07:00" example:"2025-05-01T18:00:00Z"`
+	ReminderAt  *string  `json:"reminder_at,omitempty" validate:"omitnil,datetime=2006-01-02T15:04:05Z07:00" example:"2025-05-01T09:00:00Z"`
+	Recurrence  *string  `json:"recurrence,omitempty" validate:"omitnil,max=500" example:"FREQ=WEEKLY;BYDAY=SA"`
+	Tags        []string `json:"tags,omitempty" validate:"max=20,dive,required,max=50"`
+}
+
+// removableFields maps fields of PatchRequest to whether they may be set to null.
+var removableFields = map[string]bool{
+	"title":       false,
+	"description": true,
+	"status":      false,
+	"priority":    false,
+	"project_id":  true,
+	"parent_id":   true,
+	"due_at":      true,
+	"reminder_at": true,
+	"recurrence":  true,
+	"tags":        true,
+}
+
+// parsePatch decodes a JSON Merge Patch document and returns it along with its members.
+// A non-empty message is returned if the document is malformed.
+func parsePatch(body []byte) (*PatchRequest, map[string]json.RawMessage, string) {
+	// the body is decoded twice: into a map to tell null from absent fields and into the typed request
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return nil, nil, "request body must be a JSON object"
+	}
+	req := &PatchRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, nil, "invalid request body"
+	}
+	for name, value := range fields {
+		removable, ok := removableFields[name]
+		if !ok {
+			return nil, nil, fmt.Sprintf("unknown field '%s'", name)
+		}
+		if string(value) == "null" && !removable {
+			return nil, nil, fmt.Sprintf("field '%s' can't be removed", name)
+		}
+	}
+	if string(fields["description"]) == "null" {
+		empty := ""
+		req.Description = &empty
+	}
+	if req.Status != nil {
+		status := strings.ToLower(*req.Status)
+		req.Status = &status
+	}
+	if req.Priority != nil {
+		priority := strings.ToLower(*req.Priority)
+		req.Priority = &priority
+	}
+	req.Tags = normalizeTags(req.Tags)
+	return req, fields, ""
+}
+
+// patch converts the validated request to models.TaskPatch, fields are the members of the document.
+func (r *PatchRequest) patch(id int, version int, fields map[string]json.RawMessage) *models.TaskPatch {
+	removed := func(name string) bool {
+		return string(fields[name]) == "null"
+	}
+	patch := &models.TaskPatch{
 		ID:          id,
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      req.Status,
-	})
-	if errors.Is(err, service.ErrNotFound) {
-		log.Error("task not found", sl.Err(err))
-		return response.ErrorNotFound(c)
-	} else if errors.Is(err, service.ErrConflict) {
-		log.Error("task has already been updated", sl.Err(err))
-		return response.ErrorConflict(c, "task has already been updated, try again")
-	} else if err != nil {
-		log.Error("failed to update task", sl.Err(err))
-		return response.ErrorInternal(c)
+		Version:     version,
+		Title:       r.Title,
+		Description: r.Description,
+		Status:      r.Status,
+	}
+	if r.Priority != nil {
+		priority := parsePriority(*r.Priority)
+		patch.Priority = &priority
+	}
+	if removed("project_id") {
+		patch.ProjectID = models.NullableOf[int](nil)
+	} else if r.ProjectID != nil {
+		patch.ProjectID = models.NullableOf(r.ProjectID)
+	}
+	if removed("parent_id") {
+		patch.ParentID = models.NullableOf[int](nil)
+	} else if r.ParentID != nil {
+		patch.ParentID = models.NullableOf(r.ParentID)
+	}
+	if removed("due_at") {
+		patch.DueAt = models.NullableOf[time.Time](nil)
+	} else if r.DueAt != nil {
+		patch.DueAt = models.NullableOf(parseTime(*r.DueAt))
+	}
+	if removed("reminder_at") {
+		patch.ReminderAt = models.NullableOf[time.Time](nil)
+	} else if r.ReminderAt != nil {
+		patch.ReminderAt = models.NullableOf(parseTime(*r.ReminderAt))
+	}
+	if removed("recurrence") {
+		empty := ""
+		patch.Recurrence = &empty
+	} else if r.Recurrence != nil {
+		recurrence := strings.TrimSpace(*r.Recurrence)
+		patch.Recurrence = &recurrence
+	}
+	if _, ok := fields["tags"]; ok {
+		patch.Tags = make([]string, 0, len(r.Tags))
+		patch.Tags = append(patch.Tags, r.Tags...)
+	}
+	return patch
+}
+
+// @Summary		Partially update task
+// @Description	Accepts a JSON Merge Patch (RFC 7396): only the fields present in the body are changed,
+// @Description	description, project_id, parent_id, due_at, reminder_at, recurrence and tags can be removed by setting them to null.
+// @Description	tags replace all tags of the task.
+// @Tags			tasks
+// @Accept			json
+// @Accept			application/merge-patch+json
+// @Param			id			path	int				true	"Task ID"
+// @Param			If-Match	header	string			false	"ETag of the task the patch is based on"
+// @Param			Task		body	PatchRequest	true	"Fields to change"
+// @Success		204
+// @Header			204	{string}	ETag				"New version of the task"
+// @Failure		400	{object}	response.Response	"invalid request body or task ID"
+// @Failure		404	{object}	response.Response	"task not found"
+// @Failure		409	{object}	response.Response	"task has already been updated, try again"
+// @Failure		412	{object}	response.Response	"task version doesn't match If-Match"
+// @Failure		422	{object}	response.Response	"status change is not allowed by the workflow or by subtasks and dependencies, project or parent task not found"
+// @Failure		500	{object}	response.Response	"internal server error"
+// @Router			/tasks/{id} [patch]
+func (tc *TaskController) Patch(c *fiber.Ctx) error {
+	const op = "controller.tasks.Patch"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+
+	req, fields, msg := parsePatch(c.Body())
+	if msg != "" {
+		log.Error("failed to parse request body", slog.String("reason", msg))
+		return response.ErrorBadRequest(c, msg)
+	}
+	if err = tc.validator.StructCtx(c.UserContext(), req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		log.Error("failed to parse If-Match header", sl.Err(err))
+		return ifMatchError(c, err)
+	}
+	log.Info("request received", slog.Any("data", req), slog.Int("if_match", version))
+
+	task, err := tc.uc.PatchTask(c.UserContext(), req.patch(id, version, fields))
+	if err != nil {
+		status, msg := taskError(err)
+		log.Error("failed to patch task", sl.Err(err), slog.Int("status", status))
+		return response.Error(c, status, msg)
 	}
-	log.Info("task updated", slog.Any("data", req))
+	log.Info("task patched", slog.Any("data", task))
 
+	setETag(c, task)
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// @Summary	Delete task
-// @Tags		tasks
-// @Param		id	path		int	true	"Task ID"
-// @Success	200	{object}	models.Task
-// @Failure	400	{object}	response.Response	"invalid task ID"
-// @Failure	404	{object}	response.Response	"task not found"
+// @Summary		Delete task
+// @Description	Moves the task to the trash, it can be restored until the trash is purged.
+// @Tags			tasks
+// @Param		id			path		int		true	"Task ID"
+// @Param		If-Match	header		string	false	"ETag of the task to delete"
+// @Success	200			{object}	models.Task
+// @Failure	400			{object}	response.Response	"invalid task ID"
+// @Failure	404			{object}	response.Response	"task not found"
+// @Failure	412			{object}	response.Response	"task version doesn't match If-Match"
 // @Failure	500	{object}	response.Response	"internal server error"
 // @Router		/tasks/{id} [delete]
 func (tc *TaskController) Delete(c *fiber.Ctx) error {
@@ -205,15 +826,89 @@ func (tc *TaskController) Delete(c *fiber.Ctx) error {
 		return response.ErrorBadRequest(c, "invalid task ID")
 	}
 
-	task, err := tc.uc.DeleteTask(c.UserContext(), id)
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		log.Error("failed to parse If-Match header", sl.Err(err))
+		return ifMatchError(c, err)
+	}
+
+	task, err := tc.uc.DeleteTask(c.UserContext(), id, version)
+	if err != nil {
+		status, msg := taskError(err)
+		log.Error("failed to delete task", sl.Err(err), slog.Int("status", status))
+		return response.Error(c, status, msg)
+	}
+	log.Info("task deleted", slog.Any("data", task))
+
+	return c.Status(fiber.StatusOK).JSON(task)
+}
+
+// @Summary	Restore deleted task
+// @Tags		tasks
+// @Param		id	path		int	true	"Task ID"
+// @Success	200	{object}	models.Task
+// @Failure	400	{object}	response.Response	"invalid task ID"
+// @Failure	404	{object}	response.Response	"task not found in trash"
+// @Failure	500	{object}	response.Response	"internal server error"
+// @Router		/tasks/{id}/restore [post]
+func (tc *TaskController) Restore(c *fiber.Ctx) error {
+	const op = "controller.tasks.Restore"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	log.Info("request received", slog.Int("id", id))
+
+	task, err := tc.uc.RestoreTask(c.UserContext(), id)
 	if errors.Is(err, service.ErrNotFound) {
-		log.Error("task not found", sl.Err(err))
+		log.Error("task not found in trash", sl.Err(err))
 		return response.ErrorNotFound(c)
 	} else if err != nil {
-		log.Error("failed to delete task", sl.Err(err))
+		log.Error("failed to restore task", sl.Err(err))
 		return response.ErrorInternal(c)
 	}
-	log.Info("task deleted", slog.Any("data", task))
+	log.Info("task restored", slog.Any("data", task))
 
+	setETag(c, task)
 	return c.Status(fiber.StatusOK).JSON(task)
 }
+
+type PurgeResponse struct {
+	Purged int64 `json:"purged"`
+}
+
+// @Summary		Purge trash
+// @Description	Permanently removes tasks that have been in the trash longer than the retention period.
+// @Tags			tasks
+// @Success		200	{object}	PurgeResponse
+// @Failure		500	{object}	response.Response	"internal server error"
+// @Router			/tasks/trash [delete]
+func (tc *TaskController) Purge(c *fiber.Ctx) error {
+	const op = "controller.tasks.Purge"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	log.Info("request received")
+
+	purged, err := tc.uc.PurgeTrash(c.UserContext())
+	if err != nil {
+		log.Error("failed to purge trash", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("trash purged", slog.Int64("count", purged))
+
+	return c.Status(fiber.StatusOK).JSON(PurgeResponse{Purged: purged})
+}
@@ -0,0 +1,129 @@
+package tasks
+
+import (
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []models.SortField
+		wantMsg bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "directions",
+			sort: "-priority, title",
+			want: []models.SortField{{Column: "priority", Desc: true}, {Column: "title"}},
+		},
+		{
+			name:    "unknown column",
+			sort:    "description",
+			wantMsg: true,
+		},
+		{
+			name:    "column listed twice",
+			sort:    "title,-title",
+			wantMsg: true,
+		},
+		{
+			name:    "empty column",
+			sort:    "title,",
+			wantMsg: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msg := parseSort(tt.sort)
+			if (msg != "") != tt.wantMsg {
+				t.Fatalf("parseSort() message = %q, want message: %v", msg, tt.wantMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePatch(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		body    string
+		want    *PatchRequest
+		removed []string
+		wantMsg bool
+	}{
+		{
+			name: "empty document changes nothing",
+			body: `{}`,
+			want: &PatchRequest{},
+		},
+		{
+			name: "fields are normalized",
+			body: `{"title":"Buy milk","status":"In_Progress","priority":"HIGH","tags":[" Home ","SHOP"]}`,
+			want: &PatchRequest{Title: ptr("Buy milk"), Status: ptr("in_progress"), Priority: ptr("high"), Tags: []string{"home", "shop"}},
+		},
+		{
+			name:    "null removes the field",
+			body:    `{"description":null,"due_at":null,"parent_id":null,"project_id":null,"recurrence":null,"reminder_at":null,"tags":null}`,
+			want:    &PatchRequest{Description: ptr("")},
+			removed: []string{"description", "due_at", "parent_id", "project_id", "recurrence", "reminder_at", "tags"},
+		},
+		{
+			name:    "not an object",
+			body:    `[]`,
+			wantMsg: true,
+		},
+		{
+			name:    "null document",
+			body:    `null`,
+			wantMsg: true,
+		},
+		{
+			name:    "unknown field",
+			body:    `{"done":true}`,
+			wantMsg: true,
+		},
+		{
+			name:    "required field removed",
+			body:    `{"priority":null}`,
+			wantMsg: true,
+		},
+		{
+			name:    "field of another type",
+			body:    `{"title":3}`,
+			wantMsg: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fields, msg := parsePatch([]byte(tt.body))
+			if (msg != "") != tt.wantMsg {
+				t.Fatalf("parsePatch() message = %q, want message: %v", msg, tt.wantMsg)
+			}
+			if tt.wantMsg {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePatch() = %+v, want %+v", got, tt.want)
+			}
+			var removed []string
+			for name, value := range fields {
+				if string(value) == "null" {
+					removed = append(removed, name)
+				}
+			}
+			slices.Sort(removed)
+			if !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("parsePatch() removed %v, want %v", removed, tt.removed)
+			}
+		})
+	}
+}
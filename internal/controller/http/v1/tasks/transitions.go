@@ -0,0 +1,87 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+type TransitionRequest struct {
+	To     string `json:"to" validate:"required,status"`
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// @Summary		Change task status
+// @Description	Only the status changes allowed by the workflow are accepted, some of them require a reason,
+// @Description	e.g. reopening a done task. The reason is returned as status_reason of the task.
+// @Tags			tasks
+// @Param			id			path		int					true	"Task ID"
+// @Param			If-Match	header		string				false	"ETag of the task the change is based on"
+// @Param			Transition	body		TransitionRequest	true	"New status and the reason of the change"
+// @Success		200			{object}	models.Task
+// @Header			200			{string}	ETag				"New version of the task"
+// @Failure		400			{object}	response.Response	"invalid request body or task ID"
+// @Failure		404			{object}	response.Response	"task not found"
+// @Failure		409			{object}	response.Response	"task has already been updated, try again"
+// @Failure		412			{object}	response.Response	"task version doesn't match If-Match"
+// @Failure		422			{object}	response.Response	"status change is not allowed"
+// @Failure		500			{object}	response.Response	"internal server error"
+// @Router			/tasks/{id}/transitions [post]
+func (tc *TaskController) Transition(c *fiber.Ctx) error {
+	const op = "controller.tasks.Transition"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	idParam := c.Params("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("failed to parse id param", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	if id < 0 {
+		log.Error("invalid id param", slog.Int("id", id))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	req := &TransitionRequest{}
+	if err = c.BodyParser(req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid request body")
+	}
+	req.To = strings.ToLower(req.To)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err = tc.validator.StructCtx(c.UserContext(), req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "request body validation failed")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		log.Error("failed to parse If-Match header", sl.Err(err))
+		return ifMatchError(c, err)
+	}
+	log.Info("request received", slog.Any("data", req), slog.Int("if_match", version))
+
+	task, err := tc.uc.TransitionTask(c.UserContext(), id, req.To, req.Reason, version)
+	if err != nil {
+		status, msg := taskError(err)
+		log.Error("failed to change task status", sl.Err(err), slog.Int("status", status))
+		return response.Error(c, status, msg)
+	}
+	log.Info("task status changed", slog.Any("data", task))
+
+	setETag(c, task)
+	return c.Status(fiber.StatusOK).JSON(task)
+}
@@ -0,0 +1,136 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/api/response"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"strconv"
+)
+
+type RevertRequest struct {
+	To int `query:"to" validate:"required,min=1"`
+}
+
+// @Summary		Revert task to a previous version
+// @Description	Applies the task as it was in the given version as a new update, the same rules as for PUT apply.
+// @Description	Dependencies and the position of the task are not reverted.
+// @Description	Without If-Match the request fails with 409 if the task is changed concurrently.
+// @Tags			tasks
+// @Param			id			path		int		true	"Task ID"
+// @Param			to			query		int		true	"Version to revert to"
+// @Param			If-Match	header		string	false	"ETag of the task the revert is applied to"
+// @Success		200			{object}	models.Task
+// @Failure		400			{object}	response.Response	"invalid task ID or version"
+// @Failure		404			{object}	response.Response	"task or version not found"
+// @Failure		409			{object}	response.Response	"task has already been updated, try again"
+// @Failure		412			{object}	response.Response	"task version doesn't match If-Match"
+// @Failure		422			{object}	response.Response	"status change is not allowed by the workflow or by subtasks and dependencies, project or parent task not found"
+// @Failure		500			{object}	response.Response	"internal server error"
+// @Router			/tasks/{id}/revert [post]
+func (tc *TaskController) Revert(c *fiber.Ctx) error {
+	const op = "controller.tasks.Revert"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("invalid id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	req := &RevertRequest{}
+	if err = c.QueryParser(req); err != nil {
+		log.Error("failed to parse query params", sl.Err(err))
+		return response.ErrorBadRequest(c, "invalid query parameters")
+	}
+	if err = tc.validator.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			resp := response.ValidationError(validateErr)
+			return response.ErrorBadRequest(c, resp)
+		}
+		return response.ErrorBadRequest(c, "query parameters validation failed")
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		log.Error("failed to parse If-Match header", sl.Err(err))
+		return ifMatchError(c, err)
+	}
+	log.Info("request received", slog.Int("id", id), slog.Int("to", req.To), slog.Int("if_match", version))
+
+	task, err := tc.uc.RevertTask(c.UserContext(), id, req.To, version)
+	if errors.Is(err, service.ErrInvalidInput) {
+		log.Error("unknown status", sl.Err(err))
+		return response.ErrorUnprocessableEntity(c, "status of the version no longer exists")
+	} else if errors.Is(err, service.ErrInvalidRecurrence) {
+		log.Error("invalid recurrence rule", sl.Err(err))
+		return response.ErrorUnprocessableEntity(c, "invalid recurrence rule")
+	} else if err != nil {
+		status, msg := taskError(err)
+		log.Error("failed to revert task", sl.Err(err), slog.Int("status", status))
+		return response.Error(c, status, msg)
+	}
+	log.Info("task reverted", slog.Any("data", task))
+
+	setETag(c, task)
+	return c.Status(fiber.StatusOK).JSON(task)
+}
+
+// @Summary		Undelete task
+// @Description	Takes the task out of the trash or, if the trash has been purged, recreates it from its last version.
+// @Description	A project, parent task or dependency that no longer exists is not restored, comments of a purged task are lost.
+// @Tags			tasks
+// @Param			id			path		int		true	"Task ID"
+// @Param			If-Match	header		string	false	"ETag of the deleted task"
+// @Success		200			{object}	models.Task
+// @Failure		400			{object}	response.Response	"invalid task ID"
+// @Failure		404			{object}	response.Response	"deleted task not found"
+// @Failure		412			{object}	response.Response	"task version doesn't match If-Match"
+// @Failure		422			{object}	response.Response	"status of the task no longer exists"
+// @Failure		500			{object}	response.Response	"internal server error"
+// @Router			/tasks/{id}/undelete [post]
+func (tc *TaskController) Undelete(c *fiber.Ctx) error {
+	const op = "controller.tasks.Undelete"
+	requestID := c.UserContext().Value(request_id.RequestIDKey).(string)
+	log := tc.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("invalid id param", slog.String("id", c.Params("id")))
+		return response.ErrorBadRequest(c, "invalid task ID")
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		log.Error("failed to parse If-Match header", sl.Err(err))
+		return ifMatchError(c, err)
+	}
+	log.Info("request received", slog.Int("id", id), slog.Int("if_match", version))
+
+	task, err := tc.uc.UndeleteTask(c.UserContext(), id, version)
+	if errors.Is(err, service.ErrNotFound) {
+		log.Error("deleted task not found", sl.Err(err))
+		return response.ErrorNotFound(c)
+	} else if errors.Is(err, service.ErrPreconditionFailed) {
+		log.Error("task version doesn't match", sl.Err(err))
+		return response.ErrorPreconditionFailed(c)
+	} else if errors.Is(err, service.ErrInvalidInput) {
+		log.Error("unknown status", sl.Err(err))
+		return response.ErrorUnprocessableEntity(c, "status of the task no longer exists")
+	} else if err != nil {
+		log.Error("failed to undelete task", sl.Err(err))
+		return response.ErrorInternal(c)
+	}
+	log.Info("task undeleted", slog.Any("data", task))
+
+	setETag(c, task)
+	return c.Status(fiber.StatusOK).JSON(task)
+}
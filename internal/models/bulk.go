@@ -0,0 +1,26 @@
+package models
+
+type BulkAction string
+
+const (
+	BulkCreate BulkAction = "create"
+	BulkUpdate BulkAction = "update"
+	BulkPatch  BulkAction = "patch"
+	BulkDelete BulkAction = "delete"
+)
+
+// BulkOperation is one of the operations applied together. Task is created or replaces the task
+// with Task.ID, Patch is applied by patch, ID and Version select the task to delete.
+type BulkOperation struct {
+	Action  BulkAction
+	Task    *Task
+	Patch   *TaskPatch
+	ID      int
+	Version int
+}
+
+// BulkResult is the outcome of the operation with the same index, Err is nil if it has been applied.
+type BulkResult struct {
+	Task *Task
+	Err  error
+}
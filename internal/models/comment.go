@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type Comment struct {
+	ID        int       `db:"id" json:"id"`
+	TaskID    int       `db:"task_id" json:"task_id"`
+	Body      string    `db:"body" json:"body"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type CommentPage struct {
+	Comments   []*Comment `json:"comments"`
+	Total      int        `json:"total"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type TaskAction string
+
+const (
+	TaskCreated  TaskAction = "created"
+	TaskUpdated  TaskAction = "updated"
+	TaskDeleted  TaskAction = "deleted"
+	TaskRestored TaskAction = "restored"
+)
+
+// TaskEvent records a change of the task: who made it, in which request and what fields changed.
+type TaskEvent struct {
+	ID     int64      `db:"id" json:"id"`
+	TaskID int        `db:"task_id" json:"task_id"`
+	Action TaskAction `db:"action" json:"action" swaggertype:"string" enums:"created,updated,deleted,restored"`
+	// Changes maps names of the changed fields to their values before and after the change.
+	Changes   map[string]FieldChange `db:"changes" json:"changes"`
+	RequestID string                 `db:"request_id" json:"request_id"`
+	Actor     string                 `db:"actor" json:"actor"`
+	CreatedAt time.Time              `db:"created_at" json:"created_at"`
+}
+
+// FieldChange holds JSON values of the field, From is null for created tasks.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// EventSource tells in which request and by whom the changes of tasks are made.
+type EventSource struct {
+	RequestID string
+	Actor     string
+}
+
+// Key to use when setting the event source. Non-exported type is used to avoid collisions
+type ctxKeyEventSource int
+
+const eventSourceKey ctxKeyEventSource = 0
+
+// WithEventSource returns a copy of ctx that makes the changes of tasks on behalf of source.
+func WithEventSource(ctx context.Context, source EventSource) context.Context {
+	return context.WithValue(ctx, eventSourceKey, source)
+}
+
+// EventSourceFrom returns the source of the changes made with ctx, ok is false if it isn't set.
+func EventSourceFrom(ctx context.Context) (source EventSource, ok bool) {
+	source, ok = ctx.Value(eventSourceKey).(EventSource)
+	return source, ok
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ListFilter narrows down a list of tasks. Zero values mean "no restriction".
+// Ranges include their lower bound and exclude the upper one.
+type ListFilter struct {
+	ProjectID  *int
+	Statuses   []string
+	Priorities []Priority
+	// Tags selects tasks having any of the tags.
+	Tags          []string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+	// Overdue selects unfinished tasks with due date in the past.
+	Overdue bool
+	// Title is matched as a case-insensitive substring.
+	Title string
+	// Trashed selects deleted tasks instead of the active ones.
+	Trashed bool
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is a request made with an Idempotency-Key header and the response sent to it.
+// StatusCode is 0 while the first request with the key is still being processed.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	// Headers holds the response headers that are sent again along with the body.
+	Headers   map[string]string
+	Body      []byte
+	CreatedAt time.Time
+}
@@ -0,0 +1,26 @@
+package models
+
+// ImportRow is a task read from the line of the imported file.
+type ImportRow struct {
+	Line int
+	Task *Task
+}
+
+// ImportedRow is a row that passed validation, ID is 0 if the import was a dry run.
+type ImportedRow struct {
+	Line int `json:"line"`
+	ID   int `json:"id,omitempty"`
+}
+
+// RejectedRow is a row that hasn't been imported, Message explains why.
+type RejectedRow struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// ImportReport lists rows of the imported file ordered by line.
+type ImportReport struct {
+	DryRun   bool           `json:"dry_run"`
+	Accepted []*ImportedRow `json:"accepted"`
+	Rejected []*RejectedRow `json:"rejected"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Page describes which part of a list should be returned.
+// Offset and After are mutually exclusive: After holds an opaque cursor
+// taken from NextCursor of the previous page, which is only valid with the same Sort.
+type Page struct {
+	Limit  int
+	Offset int
+	After  string
+	Sort   []SortField
+}
+
+// SortField is a column to order by. Fields are applied in the order they are listed.
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+type TaskPage struct {
+	Tasks      []*Task `json:"tasks"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
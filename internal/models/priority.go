@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Priority is stored as a number, so that tasks can be ordered by it,
+// and is represented by its name in the API. Zero value means the priority is not set.
+type Priority int16
+
+const (
+	PriorityLow Priority = iota + 1
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = [...]string{
+	PriorityLow:    "low",
+	PriorityMedium: "medium",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+// ParsePriority returns the priority with the given name.
+func ParsePriority(name string) (Priority, bool) {
+	for p, n := range priorityNames {
+		if n == name && p != 0 {
+			return Priority(p), true
+		}
+	}
+	return 0, false
+}
+
+func (p Priority) String() string {
+	if p <= 0 || int(p) >= len(priorityNames) {
+		return fmt.Sprintf("Priority(%d)", int16(p))
+	}
+	return priorityNames[p]
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	parsed, ok := ParsePriority(name)
+	if !ok {
+		return fmt.Errorf("unknown priority %q", name)
+	}
+	*p = parsed
+	return nil
+}
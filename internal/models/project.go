@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type Project struct {
+	ID          int       `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ProjectDeleteMode tells what happens to tasks of a deleted project.
+type ProjectDeleteMode string
+
+const (
+	// ProjectDeleteReject refuses to delete a project that has tasks.
+	ProjectDeleteReject ProjectDeleteMode = "reject"
+	// ProjectDeleteCascade deletes tasks of the project permanently.
+	ProjectDeleteCascade ProjectDeleteMode = "cascade"
+	// ProjectDeleteInbox moves tasks of the project to the inbox, i.e. leaves them without a project.
+	ProjectDeleteInbox ProjectDeleteMode = "inbox"
+)
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Reminder is sent to the notifier when the reminder time of the task comes.
+type Reminder struct {
+	TaskID     int        `json:"task_id"`
+	Title      string     `json:"title"`
+	Status     string     `json:"status"`
+	DueAt      *time.Time `json:"due_at,omitempty"`
+	ReminderAt time.Time  `json:"reminder_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// SearchQuery is a full-text search of tasks in the web search syntax:
+// quoted phrases, "or" between alternatives and "-" before excluded words.
+type SearchQuery struct {
+	Query string
+	// Language is the text search configuration the query is parsed with, e.g. english.
+	Language string
+	Limit    int
+	Offset   int
+}
+
+// SearchResult is a task matching the query. Highlights are fragments of the text with matched words
+// wrapped in <mark> tags, the text is HTML-escaped.
+type SearchResult struct {
+	Task                 *Task   `json:"task"`
+	Rank                 float32 `json:"rank"`
+	TitleHighlight       string  `json:"title_highlight"`
+	DescriptionHighlight string  `json:"description_highlight,omitempty"`
+}
+
+// SearchPage holds results ordered by rank, Total is the number of all matching tasks.
+type SearchPage struct {
+	Results []*SearchResult `json:"results"`
+	Total   int             `json:"total"`
+}
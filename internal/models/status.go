@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Status is a column of the board tasks move through. Tasks in terminal statuses are considered finished.
+type Status struct {
+	Name      string    `db:"name" json:"name"`
+	Position  int       `db:"position" json:"position"`
+	Terminal  bool      `db:"terminal" json:"terminal"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type Tag struct {
+	ID        int       `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// NormalizeTagName makes tag names case-insensitive.
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
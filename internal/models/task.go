@@ -2,11 +2,104 @@ package models
 
 import "time"
 
+// Built-in statuses, more of them can be added to the statuses table.
+const (
+	StatusNew        = "new"
+	StatusInProgress = "in_progress"
+	StatusDone       = "done"
+)
+
 type Task struct {
-	ID          int       `db:"id" json:"id"`
-	Title       string    `db:"title" json:"title"`
-	Description string    `db:"description" json:"description,omitempty"`
-	Status      string    `db:"status" json:"status,omitempty"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	ID          int    `db:"id" json:"id"`
+	Title       string `db:"title" json:"title"`
+	Description string `db:"description" json:"description,omitempty"`
+	Status      string `db:"status" json:"status,omitempty"`
+	// StatusReason explains the last status change if the workflow required it.
+	StatusReason string   `db:"status_reason" json:"status_reason,omitempty"`
+	Priority     Priority `db:"priority" json:"priority" swaggertype:"string" enums:"low,medium,high,urgent"`
+	// Position orders tasks within a status column.
+	Position int64 `db:"position" json:"position"`
+	// ProjectID is nil for tasks in the inbox.
+	ProjectID *int `db:"project_id" json:"project_id,omitempty"`
+	// ParentID is set for subtasks.
+	ParentID *int `db:"parent_id" json:"parent_id,omitempty"`
+	// Recurrence is an iCalendar RRULE with DTSTART, the next occurrence of a recurring task
+	// is created when the task is done. All occurrences share the SeriesID.
+	Recurrence string     `db:"recurrence" json:"recurrence,omitempty" example:"DTSTART:20250503T100000Z\nRRULE:FREQ=WEEKLY;BYDAY=SA"`
+	SeriesID   *int       `db:"series_id" json:"series_id,omitempty"`
+	DueAt      *time.Time `db:"due_at" json:"due_at,omitempty"`
+	// ReminderAt is when the reminder about the task is sent, ReminderSentAt is set once it has been sent.
+	ReminderAt     *time.Time `db:"reminder_at" json:"reminder_at,omitempty"`
+	ReminderSentAt *time.Time `db:"reminder_sent_at" json:"reminder_sent_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at,omitempty"`
+	// Version is incremented on every update and is used as the ETag of the task.
+	Version int `db:"version" json:"version"`
+	// DeletedAt is set when the task is moved to the trash.
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	Tags      []string   `db:"tags" json:"tags"`
+	// BlockedBy lists ids of tasks that must be done before this one.
+	BlockedBy []int `db:"blocked_by" json:"blocked_by"`
+	// Blocked is true if some of the BlockedBy tasks are not done yet.
+	Blocked bool `db:"blocked" json:"blocked"`
+	// CommentCount is the number of comments in the discussion of the task.
+	CommentCount int `db:"comment_count" json:"comment_count"`
+}
+
+// TaskPatch is a partial update of the task with the given ID.
+// Nil fields are left unchanged.
+type TaskPatch struct {
+	ID int
+	// Version is the version the client expects the task to have, 0 skips the check.
+	Version     int
+	Title       *string
+	Description *string
+	Status      *string
+	// StatusReason is saved along with a new status.
+	StatusReason *string
+	Priority     *Priority
+	ProjectID    Nullable[int]
+	ParentID     Nullable[int]
+	DueAt        Nullable[time.Time]
+	// ReminderAt reschedules the reminder, a new time makes it be sent again.
+	ReminderAt Nullable[time.Time]
+	// Recurrence replaces the rule of the task, empty rule stops the recurrence.
+	Recurrence *string
+	// Tags replace all tags of the task if not nil, an empty slice removes them.
+	Tags []string
+}
+
+// SeriesPatch changes all occurrences of the series that are not done yet.
+// Nil fields are left unchanged.
+type SeriesPatch struct {
+	Title       *string
+	Description *string
+	Priority    *Priority
+	Recurrence  *string
+}
+
+// TaskMove puts the task between two neighbours of the target status column.
+// Without neighbours the task is moved to the end of the column.
+type TaskMove struct {
+	ID int
+	// Version is the version the client expects the task to have, 0 skips the check.
+	Version int
+	// Status is the target column, empty status keeps the current one.
+	Status string
+	// StatusReason is saved only if the status changes.
+	StatusReason string
+	AfterID      *int
+	BeforeID     *int
+}
+
+// Nullable is a patch of a nullable column. The column is changed only if Set is true,
+// nil Value sets it to NULL.
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// NullableOf returns a patch setting the column to v, which may be nil.
+func NullableOf[T any](v *T) Nullable[T] {
+	return Nullable[T]{Set: true, Value: v}
 }
@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"context"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"log/slog"
+)
+
+// Log writes reminders to the application log, it is used when no other notifier is configured.
+type Log struct {
+	log *slog.Logger
+}
+
+func NewLog(log *slog.Logger) *Log {
+	return &Log{log: log}
+}
+
+func (l *Log) Notify(_ context.Context, reminder *models.Reminder) error {
+	l.log.Info("task reminder",
+		slog.Int("task_id", reminder.TaskID),
+		slog.String("title", reminder.Title),
+		slog.Any("due_at", reminder.DueAt),
+	)
+	return nil
+}
@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/igorgrichanov/toDoList/internal/config"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// headerEscaper keeps titles from breaking out of the Subject header.
+var headerEscaper = strings.NewReplacer("\r", " ", "\n", " ")
+
+// SMTP emails reminders to the configured recipients.
+type SMTP struct {
+	conf *config.SMTP
+}
+
+func NewSMTP(conf *config.SMTP) *SMTP {
+	return &SMTP{conf: conf}
+}
+
+// Notify sends the email the same way as smtp.SendMail does, the connection is closed when ctx is done.
+func (s *SMTP) Notify(ctx context.Context, reminder *models.Reminder) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.conf.Host, s.conf.Port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("failed to set deadline: %w", err)
+		}
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	if err = s.send(conn, reminder); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to send email: %w", ctx.Err())
+		}
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func (s *SMTP) send(conn net.Conn, reminder *models.Reminder) error {
+	c, err := smtp.NewClient(conn, s.conf.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: s.conf.Host}); err != nil {
+			return err
+		}
+	}
+	if s.conf.User != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err = c.Auth(smtp.PlainAuth("", s.conf.User, s.conf.Password, s.conf.Host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(s.conf.From); err != nil {
+		return err
+	}
+	for _, to := range s.conf.To {
+		if err = c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(s.message(reminder)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s *SMTP) message(reminder *models.Reminder) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.conf.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.conf.To, ", "))
+	fmt.Fprintf(&b, "Subject: Reminder: %s\r\n", headerEscaper.Replace(reminder.Title))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&b, "Task #%d \"%s\" is %s.\r\n", reminder.TaskID, reminder.Title, reminder.Status)
+	if reminder.DueAt != nil {
+		fmt.Fprintf(&b, "It is due at %s.\r\n", reminder.DueAt.Format(time.RFC1123))
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,186 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	commentsTable = "comments"
+	tasksTable    = "tasks"
+)
+
+var commentColumns = []string{"id", "task_id", "body", "created_at", "updated_at"}
+
+type Comments struct {
+	log *slog.Logger
+	db  *postgres.Postgres
+}
+
+func NewCommentsRepository(log *slog.Logger, db *postgres.Postgres) *Comments {
+	return &Comments{log: log, db: db}
+}
+
+// Create adds the comment to the task, ErrNotFound is returned if the task doesn't exist or is in the trash.
+func (c *Comments) Create(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	var res *models.Comment
+	err := c.db.WithTx(ctx, func(ctx context.Context) error {
+		if err := c.taskExists(ctx, comment.TaskID, true); err != nil {
+			return err
+		}
+		createdAt := time.Now().UTC()
+		sql, args, err := c.db.Builder.Insert(commentsTable).
+			Columns("task_id", "body", "created_at", "updated_at").
+			Values(comment.TaskID, comment.Body, createdAt, createdAt).
+			Suffix("RETURNING " + strings.Join(commentColumns, ", ")).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		res, err = scanComment(c.db.Conn(ctx).QueryRow(ctx, sql, args...))
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// List returns comments of the task from the oldest to the newest. After is the id of the last comment
+// of the previous page, ErrInvalidInput is returned if it is malformed.
+func (c *Comments) List(ctx context.Context, taskID int, page *models.Page) (*models.CommentPage, error) {
+	if err := c.taskExists(ctx, taskID, false); err != nil {
+		return nil, err
+	}
+	sql, args, err := c.db.Builder.Select("COUNT(*)").From(commentsTable).Where("task_id = ?", taskID).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var total int
+	if err = c.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+
+	// one extra row is requested to find out whether there is a next page
+	query := c.db.Builder.Select(commentColumns...).From(commentsTable).
+		Where("task_id = ?", taskID).OrderBy("id").Limit(uint64(page.Limit + 1))
+	if page.After != "" {
+		afterID, err := strconv.Atoi(page.After)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", repository.ErrInvalidInput)
+		}
+		query = query.Where("id > ?", afterID)
+	}
+	sql, args, err = query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := c.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+
+	comments := make([]*models.Comment, 0, page.Limit+1)
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		comments = append(comments, comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+
+	res := &models.CommentPage{Comments: comments, Total: total}
+	if len(comments) > page.Limit {
+		res.Comments = comments[:page.Limit]
+		res.NextCursor = strconv.Itoa(res.Comments[len(res.Comments)-1].ID)
+	}
+	return res, nil
+}
+
+// Update changes the text of the comment of the task.
+func (c *Comments) Update(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	sql, args, err := c.db.Builder.Update(commentsTable).
+		Set("body", comment.Body).
+		Set("updated_at", time.Now().UTC()).
+		Where("id = ?", comment.ID).
+		Where("task_id = ?", comment.TaskID).
+		Where("EXISTS (SELECT 1 FROM tasks WHERE tasks.id = comments.task_id AND tasks.deleted_at IS NULL)").
+		Suffix("RETURNING " + strings.Join(commentColumns, ", ")).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	res, err := scanComment(c.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return res, nil
+}
+
+// Delete removes the comment of the task.
+func (c *Comments) Delete(ctx context.Context, taskID int, id int) (*models.Comment, error) {
+	sql, args, err := c.db.Builder.Delete(commentsTable).
+		Where("id = ?", id).
+		Where("task_id = ?", taskID).
+		Where("EXISTS (SELECT 1 FROM tasks WHERE tasks.id = comments.task_id AND tasks.deleted_at IS NULL)").
+		Suffix("RETURNING " + strings.Join(commentColumns, ", ")).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	res, err := scanComment(c.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return res, nil
+}
+
+// taskExists returns ErrNotFound if the task doesn't exist or is in the trash,
+// lock keeps the task from being moved to the trash until the end of the transaction.
+func (c *Comments) taskExists(ctx context.Context, taskID int, lock bool) error {
+	query := c.db.Builder.Select("1").From(tasksTable).
+		Where("id = ?", taskID).Where("deleted_at IS NULL")
+	if lock {
+		query = query.Suffix("FOR SHARE")
+	}
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var exists int
+	err = c.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&exists)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.ErrNotFound
+	} else if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+func scanComment(row pgx.Row) (*models.Comment, error) {
+	var comment models.Comment
+	err := row.Scan(&comment.ID, &comment.TaskID, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
@@ -0,0 +1,108 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+const (
+	keysTable = "idempotency_keys"
+)
+
+type Keys struct {
+	log *slog.Logger
+	db  *postgres.Postgres
+}
+
+func NewKeysRepository(log *slog.Logger, db *postgres.Postgres) *Keys {
+	return &Keys{log: log, db: db}
+}
+
+// Reserve stores the key for a request being processed and holds it until lockedUntil. Keys created before
+// expiredBefore are overwritten, so are keys of the same request whose holder hasn't saved the response
+// before its lease expired. If the key is already in use, the existing record is returned with reserved == false.
+func (k *Keys) Reserve(ctx context.Context, key, requestHash string, expiredBefore, lockedUntil time.Time) (rec *models.IdempotencyRecord, reserved bool, err error) {
+	createdAt := time.Now().UTC()
+	sql, args, err := k.db.Builder.Insert(keysTable).
+		Columns("key", "request_hash", "created_at", "locked_until").
+		Values(key, requestHash, createdAt, lockedUntil).
+		Suffix(`ON CONFLICT (key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_headers = NULL, response_body = NULL,
+				created_at = EXCLUDED.created_at, locked_until = EXCLUDED.locked_until
+			WHERE idempotency_keys.created_at < ?
+				OR idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until < EXCLUDED.created_at
+					AND idempotency_keys.request_hash = EXCLUDED.request_hash
+			RETURNING key`, expiredBefore).ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var inserted string
+	err = k.db.Pool.QueryRow(ctx, sql, args...).Scan(&inserted)
+	if err == nil {
+		return &models.IdempotencyRecord{Key: key, RequestHash: requestHash, CreatedAt: createdAt}, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+
+	// the key is in use and has not expired yet
+	sql, args, err = k.db.Builder.Select("key", "request_hash", "status_code", "response_headers", "response_body", "created_at").
+		From(keysTable).Where("key = ?", key).ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rec = &models.IdempotencyRecord{}
+	var statusCode *int
+	err = k.db.Pool.QueryRow(ctx, sql, args...).
+		Scan(&rec.Key, &rec.RequestHash, &statusCode, &rec.Headers, &rec.Body, &rec.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// released by the request holding it after the insert above
+			return nil, false, fmt.Errorf("%w: key has been released concurrently", repository.ErrConcurrentDelete)
+		}
+		return nil, false, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	if statusCode != nil {
+		rec.StatusCode = *statusCode
+	}
+	return rec, false, nil
+}
+
+// Save stores the response sent to the request holding the key.
+func (k *Keys) Save(ctx context.Context, key string, statusCode int, headers map[string]string, body []byte) error {
+	sql, args, err := k.db.Builder.Update(keysTable).
+		Set("status_code", statusCode).
+		Set("response_headers", headers).
+		Set("response_body", body).
+		Where("key = ?", key).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	res, err := k.db.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// Release removes the key, so that the request may be retried.
+func (k *Keys) Release(ctx context.Context, key string) error {
+	sql, args, err := k.db.Builder.Delete(keysTable).Where("key = ?", key).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = k.db.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
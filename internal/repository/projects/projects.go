@@ -0,0 +1,195 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	projectsTable = "projects"
+	tasksTable    = "tasks"
+)
+
+var projectColumns = []string{"id", "name", "description", "created_at", "updated_at"}
+
+// TasksRepository takes the tasks out of the deleted project, recording the changes in their history.
+type TasksRepository interface {
+	DetachProject(ctx context.Context, projectID int, trash bool) error
+}
+
+type Projects struct {
+	log   *slog.Logger
+	db    *postgres.Postgres
+	tasks TasksRepository
+}
+
+func NewProjectsRepository(log *slog.Logger, db *postgres.Postgres, tasks TasksRepository) *Projects {
+	return &Projects{log: log, db: db, tasks: tasks}
+}
+
+func (p *Projects) Create(ctx context.Context, project *models.Project) (*models.Project, error) {
+	createdAt := time.Now().UTC()
+	sql, args, err := p.db.Builder.Insert(projectsTable).
+		Columns("name", "description", "created_at", "updated_at").
+		Values(project.Name, project.Description, createdAt, createdAt).
+		Suffix("RETURNING \"id\"").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if err = p.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&project.ID); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	project.CreatedAt = createdAt
+	project.UpdatedAt = createdAt
+	return project, nil
+}
+
+func (p *Projects) List(ctx context.Context) ([]*models.Project, error) {
+	sql, args, err := p.db.Builder.Select(projectColumns...).From(projectsTable).OrderBy("id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := p.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+
+	projects := make([]*models.Project, 0, 20)
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	return projects, nil
+}
+
+func (p *Projects) GetByID(ctx context.Context, id int) (*models.Project, error) {
+	sql, args, err := p.db.Builder.Select(projectColumns...).From(projectsTable).Where("id = ?", id).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	project, err := scanProject(p.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return project, nil
+}
+
+func (p *Projects) Update(ctx context.Context, project *models.Project) (*models.Project, error) {
+	sql, args, err := p.db.Builder.Update(projectsTable).
+		Set("name", project.Name).
+		Set("description", project.Description).
+		Set("updated_at", time.Now().UTC()).
+		Where("id = ?", project.ID).
+		Suffix("RETURNING " + strings.Join(projectColumns, ", ")).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	res, err := scanProject(p.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return res, nil
+}
+
+// Delete deletes the project and handles its tasks according to the mode, in the cascade mode
+// the tasks are moved to the trash and the inbox.
+// In the reject mode ErrNotEmpty is returned if the project has tasks that are not in the trash,
+// trashed tasks are moved to the inbox so that they can still be restored.
+func (p *Projects) Delete(ctx context.Context, id int, mode models.ProjectDeleteMode) (*models.Project, error) {
+	var res *models.Project
+	err := p.db.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = p.delete(ctx, id, mode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *Projects) delete(ctx context.Context, id int, mode models.ProjectDeleteMode) (*models.Project, error) {
+	// the project row is locked so that no tasks are added to it concurrently
+	sql, args, err := p.db.Builder.Select(projectColumns...).From(projectsTable).
+		Where("id = ?", id).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	project, err := scanProject(p.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+
+	switch mode {
+	case models.ProjectDeleteReject:
+		sql, args, err = p.db.Builder.Select("COUNT(*)").From(tasksTable).
+			Where(squirrel.Eq{"project_id": id, "deleted_at": nil}).ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		var active int
+		if err = p.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&active); err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+		if active > 0 {
+			return nil, fmt.Errorf("%w: project has %d tasks", repository.ErrNotEmpty, active)
+		}
+		if err = p.tasks.DetachProject(ctx, id, false); err != nil {
+			return nil, err
+		}
+	case models.ProjectDeleteCascade:
+		// tasks are moved to the trash rather than deleted, so that they can be restored
+		if err = p.tasks.DetachProject(ctx, id, true); err != nil {
+			return nil, err
+		}
+	case models.ProjectDeleteInbox:
+		if err = p.tasks.DetachProject(ctx, id, false); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown delete mode '%s'", repository.ErrInvalidInput, mode)
+	}
+
+	sql, args, err = p.db.Builder.Delete(projectsTable).Where("id = ?", id).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = p.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return project, nil
+}
+
+func scanProject(row pgx.Row) (*models.Project, error) {
+	var project models.Project
+	err := row.Scan(&project.ID, &project.Name, &project.Description, &project.CreatedAt, &project.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
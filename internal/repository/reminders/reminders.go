@@ -0,0 +1,94 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/pkg/postgres"
+	"log/slog"
+	"time"
+)
+
+const (
+	tasksTable = "tasks"
+)
+
+type Reminders struct {
+	log *slog.Logger
+	db  *postgres.Postgres
+}
+
+func NewRemindersRepository(log *slog.Logger, db *postgres.Postgres) *Reminders {
+	return &Reminders{log: log, db: db}
+}
+
+// Claim leases up to limit reminders due by now until now plus lease and returns them. Leased reminders
+// are not claimed again by this or other replicas until the lease expires, so the ones that failed
+// to send are retried after that. Reminders of done and deleted tasks are not claimed.
+func (r *Reminders) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.Reminder, error) {
+	due := r.db.Builder.Select("id").From(tasksTable).
+		Where("reminder_at <= ?", now).
+		Where("reminder_sent_at IS NULL").
+		Where("deleted_at IS NULL").
+		Where("status NOT IN (SELECT name FROM statuses WHERE terminal)").
+		Where("(reminder_claimed_until IS NULL OR reminder_claimed_until <= ?)", now).
+		OrderBy("reminder_at").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+	sql, args, err := r.db.Builder.Update(tasksTable).
+		Set("reminder_claimed_until", now.Add(lease)).
+		Where(due.Prefix("id IN (").Suffix(")")).
+		Suffix("RETURNING id, title, status, due_at, reminder_at").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	return r.query(ctx, sql, args, limit)
+}
+
+// MarkSent marks the reminders as sent at the given time. A reminder whose time has been changed
+// since it was claimed is left to be sent at the new time.
+func (r *Reminders) MarkSent(ctx context.Context, reminders []*models.Reminder, sentAt time.Time) error {
+	if len(reminders) == 0 {
+		return nil
+	}
+	sent := make(squirrel.Or, 0, len(reminders))
+	for _, reminder := range reminders {
+		sent = append(sent, squirrel.Eq{"id": reminder.TaskID, "reminder_at": reminder.ReminderAt})
+	}
+	// the version is not changed, the reminder being sent is not an edit of the task
+	sql, args, err := r.db.Builder.Update(tasksTable).
+		Set("reminder_sent_at", sentAt).
+		Set("reminder_claimed_until", nil).
+		Where(sent).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = r.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+func (r *Reminders) query(ctx context.Context, sql string, args []any, capacity int) ([]*models.Reminder, error) {
+	rows, err := r.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+
+	reminders := make([]*models.Reminder, 0, capacity)
+	for rows.Next() {
+		var reminder models.Reminder
+		err = rows.Scan(&reminder.TaskID, &reminder.Title, &reminder.Status, &reminder.DueAt, &reminder.ReminderAt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		reminders = append(reminders, &reminder)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	return reminders, nil
+}
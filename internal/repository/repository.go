@@ -11,5 +11,10 @@ var (
 	ErrCreatingTx       = errors.New("error creating tx")
 	ErrConcurrentUpdate = errors.New("error concurrent update")
 	ErrConcurrentDelete = errors.New("error concurrent delete")
+	ErrVersionMismatch  = errors.New("version mismatch")
+	ErrRelatedNotFound  = errors.New("related entity not found")
+	ErrNotEmpty         = errors.New("not empty")
 	ErrInvalidInput     = errors.New("invalid input")
+	ErrParentNotFound   = errors.New("parent not found")
+	ErrCycle            = errors.New("cycle")
 )
@@ -0,0 +1,98 @@
+package statuses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+	"time"
+)
+
+const (
+	statusesTable = "statuses"
+)
+
+var statusColumns = []string{"name", "position", "terminal", "created_at"}
+
+type Statuses struct {
+	log *slog.Logger
+	db  *postgres.Postgres
+}
+
+func NewStatusesRepository(log *slog.Logger, db *postgres.Postgres) *Statuses {
+	return &Statuses{log: log, db: db}
+}
+
+// Create adds the status, zero position puts it after all existing statuses.
+func (s *Statuses) Create(ctx context.Context, status *models.Status) (*models.Status, error) {
+	createdAt := time.Now().UTC()
+	var position any = status.Position
+	if status.Position == 0 {
+		position = squirrel.Expr("(SELECT COALESCE(MAX(position), 0) + 1 FROM statuses)")
+	}
+	sql, args, err := s.db.Builder.Insert(statusesTable).
+		Columns("name", "position", "terminal", "created_at").
+		Values(status.Name, position, status.Terminal, createdAt).
+		Suffix("RETURNING \"position\"").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	err = s.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&status.Position)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	status.CreatedAt = createdAt
+	return status, nil
+}
+
+// List returns statuses in the board order.
+func (s *Statuses) List(ctx context.Context) ([]*models.Status, error) {
+	sql, args, err := s.db.Builder.Select(statusColumns...).From(statusesTable).OrderBy("position", "name").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := s.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+
+	statuses := make([]*models.Status, 0, 8)
+	for rows.Next() {
+		var status models.Status
+		if err := rows.Scan(&status.Name, &status.Position, &status.Terminal, &status.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		statuses = append(statuses, &status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	return statuses, nil
+}
+
+func (s *Statuses) GetByName(ctx context.Context, name string) (*models.Status, error) {
+	sql, args, err := s.db.Builder.Select(statusColumns...).From(statusesTable).Where("name = ?", name).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var status models.Status
+	err = s.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&status.Name, &status.Position, &status.Terminal, &status.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return &status, nil
+}
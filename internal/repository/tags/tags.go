@@ -0,0 +1,136 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+	"time"
+)
+
+const (
+	tagsTable = "tags"
+)
+
+var tagColumns = []string{"id", "name", "created_at"}
+
+type Tags struct {
+	log *slog.Logger
+	db  *postgres.Postgres
+}
+
+func NewTagsRepository(log *slog.Logger, db *postgres.Postgres) *Tags {
+	return &Tags{log: log, db: db}
+}
+
+func (t *Tags) Create(ctx context.Context, tag *models.Tag) (*models.Tag, error) {
+	createdAt := time.Now().UTC()
+	sql, args, err := t.db.Builder.Insert(tagsTable).
+		Columns("name", "created_at").
+		Values(tag.Name, createdAt).
+		Suffix("RETURNING \"id\"").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&tag.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	tag.CreatedAt = createdAt
+	return tag, nil
+}
+
+func (t *Tags) List(ctx context.Context) ([]*models.Tag, error) {
+	sql, args, err := t.db.Builder.Select(tagColumns...).From(tagsTable).OrderBy("name").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+
+	tags := make([]*models.Tag, 0, 20)
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		tags = append(tags, &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	return tags, nil
+}
+
+func (t *Tags) GetByID(ctx context.Context, id int) (*models.Tag, error) {
+	sql, args, err := t.db.Builder.Select(tagColumns...).From(tagsTable).Where("id = ?", id).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var tag models.Tag
+	err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&tag.ID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return &tag, nil
+}
+
+// Update renames the tag.
+func (t *Tags) Update(ctx context.Context, tag *models.Tag) (*models.Tag, error) {
+	sql, args, err := t.db.Builder.Update(tagsTable).
+		Set("name", tag.Name).
+		Where("id = ?", tag.ID).
+		Suffix("RETURNING id, name, created_at").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var res models.Tag
+	err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&res.ID, &res.Name, &res.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return &res, nil
+}
+
+// Delete removes the tag from all tasks and deletes it.
+func (t *Tags) Delete(ctx context.Context, id int) (*models.Tag, error) {
+	sql, args, err := t.db.Builder.Delete(tagsTable).Where("id = ?", id).
+		Suffix("RETURNING id, name, created_at").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var tag models.Tag
+	err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&tag.ID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return &tag, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
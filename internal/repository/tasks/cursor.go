@@ -0,0 +1,145 @@
+package tasks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"strings"
+	"time"
+)
+
+// sortColumn describes a column the list of tasks can be ordered by.
+type sortColumn struct {
+	// value returns the column value of the task, it is stored in the cursor
+	value func(task *models.Task) any
+	// decode restores the value stored in the cursor with its original type
+	decode func(raw json.RawMessage) (any, error)
+}
+
+// sortColumns is the whitelist of columns available for sorting.
+var sortColumns = map[string]sortColumn{
+	"id":         {value: func(t *models.Task) any { return t.ID }, decode: decodeAs[int]},
+	"title":      {value: func(t *models.Task) any { return t.Title }, decode: decodeAs[string]},
+	"status":     {value: func(t *models.Task) any { return t.Status }, decode: decodeAs[string]},
+	"priority":   {value: func(t *models.Task) any { return int16(t.Priority) }, decode: decodeAs[int16]},
+	"position":   {value: func(t *models.Task) any { return t.Position }, decode: decodeAs[int64]},
+	"created_at": {value: func(t *models.Task) any { return t.CreatedAt }, decode: decodeAs[time.Time]},
+	"updated_at": {value: func(t *models.Task) any { return t.UpdatedAt }, decode: decodeAs[time.Time]},
+}
+
+func decodeAs[T any](raw json.RawMessage) (any, error) {
+	var v T
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// normalizeSort checks that all columns are sortable and appends id as the
+// last sort key, so that the order is stable and a cursor always identifies a single row.
+func normalizeSort(fields []models.SortField) ([]models.SortField, error) {
+	res := make([]models.SortField, 0, len(fields)+1)
+	for _, f := range fields {
+		if _, ok := sortColumns[f.Column]; !ok {
+			return nil, fmt.Errorf("%w: column %q is not sortable", repository.ErrInvalidInput, f.Column)
+		}
+		res = append(res, f)
+		if f.Column == "id" {
+			// id is unique, following columns would never be compared
+			return res, nil
+		}
+	}
+	return append(res, models.SortField{Column: "id"}), nil
+}
+
+func orderBy(fields []models.SortField) []string {
+	res := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Desc {
+			res = append(res, f.Column+" DESC")
+		} else {
+			res = append(res, f.Column+" ASC")
+		}
+	}
+	return res
+}
+
+// sortKey is stored in the cursor to detect cursors issued for another order.
+func sortKey(fields []models.SortField) string {
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Desc {
+			keys = append(keys, "-"+f.Column)
+		} else {
+			keys = append(keys, f.Column)
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// cursor points to the last task of a page. Clients receive it as an opaque
+// base64 token, so its layout may change without breaking the API.
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+func encodeCursor(fields []models.SortField, task *models.Task) (string, error) {
+	cur := cursor{Sort: sortKey(fields), Values: make([]json.RawMessage, 0, len(fields))}
+	for _, f := range fields {
+		v, err := json.Marshal(sortColumns[f.Column].value(task))
+		if err != nil {
+			return "", err
+		}
+		cur.Values = append(cur.Values, v)
+	}
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor returns values of the sort columns stored in the token.
+func decodeCursor(token string, fields []models.SortField) ([]any, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", repository.ErrInvalidInput)
+	}
+	var cur cursor
+	if err = json.Unmarshal(b, &cur); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", repository.ErrInvalidInput)
+	}
+	if cur.Sort != sortKey(fields) || len(cur.Values) != len(fields) {
+		return nil, fmt.Errorf("%w: cursor was issued for another sort order", repository.ErrInvalidInput)
+	}
+	values := make([]any, 0, len(fields))
+	for i, f := range fields {
+		v, err := sortColumns[f.Column].decode(cur.Values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", repository.ErrInvalidInput)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// afterCursor builds the keyset condition selecting rows that follow the cursor:
+// (a > $1) OR (a = $1 AND b < $2) OR ... with the comparison depending on the sort direction.
+func afterCursor(fields []models.SortField, values []any) squirrel.Sqlizer {
+	cond := make(squirrel.Or, 0, len(fields))
+	for i, f := range fields {
+		and := make(squirrel.And, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, squirrel.Eq{fields[j].Column: values[j]})
+		}
+		if f.Desc {
+			and = append(and, squirrel.Lt{f.Column: values[i]})
+		} else {
+			and = append(and, squirrel.Gt{f.Column: values[i]})
+		}
+		cond = append(cond, and)
+	}
+	return cond
+}
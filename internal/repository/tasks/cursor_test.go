@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []models.SortField
+		want    []models.SortField
+		wantErr bool
+	}{
+		{
+			name: "empty sort is ordered by id",
+			want: []models.SortField{{Column: "id"}},
+		},
+		{
+			name:   "id is appended as the last key",
+			fields: []models.SortField{{Column: "priority", Desc: true}, {Column: "title"}},
+			want:   []models.SortField{{Column: "priority", Desc: true}, {Column: "title"}, {Column: "id"}},
+		},
+		{
+			name:   "columns after id are dropped",
+			fields: []models.SortField{{Column: "status"}, {Column: "id", Desc: true}, {Column: "title"}},
+			want:   []models.SortField{{Column: "status"}, {Column: "id", Desc: true}},
+		},
+		{
+			name:    "unknown column",
+			fields:  []models.SortField{{Column: "description"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeSort(tt.fields)
+			if tt.wantErr {
+				if !errors.Is(err, repository.ErrInvalidInput) {
+					t.Fatalf("normalizeSort() error = %v, want ErrInvalidInput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeSort() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeSort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 17, 10, 30, 0, 123456000, time.UTC)
+	task := &models.Task{ID: 42, Title: "Buy milk", Status: "new", Priority: models.PriorityHigh, Position: 1 << 20, CreatedAt: createdAt}
+	tests := []struct {
+		name   string
+		fields []models.SortField
+		want   []any
+	}{
+		{
+			name:   "id",
+			fields: []models.SortField{{Column: "id"}},
+			want:   []any{42},
+		},
+		{
+			name:   "every type of column",
+			fields: []models.SortField{{Column: "title"}, {Column: "priority", Desc: true}, {Column: "position"}, {Column: "created_at"}, {Column: "id"}},
+			want:   []any{"Buy milk", int16(models.PriorityHigh), int64(1 << 20), createdAt, 42},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := encodeCursor(tt.fields, task)
+			if err != nil {
+				t.Fatalf("encodeCursor() error = %v", err)
+			}
+			got, err := decodeCursor(token, tt.fields)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCursor() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	byTitle := []models.SortField{{Column: "title"}, {Column: "id"}}
+	token, err := encodeCursor(byTitle, &models.Task{ID: 1, Title: "a"})
+	if err != nil {
+		t.Fatalf("encodeCursor() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		token  string
+		fields []models.SortField
+	}{
+		{name: "not base64", token: "!!!", fields: byTitle},
+		{name: "not JSON", token: "bm90IGpzb24", fields: byTitle},
+		{name: "another direction", token: token, fields: []models.SortField{{Column: "title", Desc: true}, {Column: "id"}}},
+		{name: "another column", token: token, fields: []models.SortField{{Column: "status"}, {Column: "id"}}},
+		{name: "value of another type", token: "eyJzIjoiaWQiLCJ2IjpbImEiXX0", fields: []models.SortField{{Column: "id"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.token, tt.fields); !errors.Is(err, repository.ErrInvalidInput) {
+				t.Errorf("decodeCursor() error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
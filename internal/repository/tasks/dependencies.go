@@ -0,0 +1,139 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
+	"time"
+)
+
+// dependsOnCTE selects ids of all tasks the task depends on, directly or transitively.
+const dependsOnCTE = `WITH RECURSIVE depends_on (task_id) AS (
+	SELECT blocked_by_id FROM task_dependencies WHERE task_id = ?
+	UNION
+	SELECT d.blocked_by_id FROM task_dependencies d JOIN depends_on ON d.task_id = depends_on.task_id
+)`
+
+// AddDependency makes the task blocked by another one. Adding an existing dependency changes nothing.
+// ErrNotFound is returned if either of the tasks doesn't exist and ErrCycle if the other task
+// already depends on the task.
+func (t *Tasks) AddDependency(ctx context.Context, taskID int, blockedByID int) (*models.Task, error) {
+	var task *models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		before, err := t.lockTask(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		// dependencies are added one at a time, so that concurrent additions can't make a cycle together
+		if err = t.advisoryLock(ctx, dependenciesTable); err != nil {
+			return err
+		}
+		cycle, err := t.DependsOn(ctx, blockedByID, taskID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return fmt.Errorf("%w: task %d depends on task %d", repository.ErrCycle, blockedByID, taskID)
+		}
+		sql, args, err := t.db.Builder.Insert(dependenciesTable).
+			Columns("task_id", "blocked_by_id").
+			Values(taskID, blockedByID).
+			Suffix("ON CONFLICT DO NOTHING").ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		res, err := t.db.Conn(ctx).Exec(ctx, sql, args...)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign key violation
+				return fmt.Errorf("%w: %s", repository.ErrNotFound, pgErr.ConstraintName)
+			}
+			return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+		if res.RowsAffected() == 0 {
+			task, err = t.GetByID(ctx, taskID)
+			return err
+		}
+		if task, err = t.touch(ctx, taskID); err != nil {
+			return err
+		}
+		return t.recordEvent(ctx, models.TaskUpdated, before, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// RemoveDependency deletes the dependency, ErrNotFound is returned if there is no such dependency.
+func (t *Tasks) RemoveDependency(ctx context.Context, taskID int, blockedByID int) (*models.Task, error) {
+	var task *models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		before, err := t.lockTask(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		sql, args, err := t.db.Builder.Delete(dependenciesTable).
+			Where("task_id = ?", taskID).
+			Where("blocked_by_id = ?", blockedByID).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		res, err := t.db.Conn(ctx).Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+		if res.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+		if task, err = t.touch(ctx, taskID); err != nil {
+			return err
+		}
+		return t.recordEvent(ctx, models.TaskUpdated, before, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// DependsOn tells whether the task depends on another one, directly or transitively.
+func (t *Tasks) DependsOn(ctx context.Context, taskID int, otherID int) (bool, error) {
+	sql, args, err := t.db.Builder.Select("1").Prefix(dependsOnCTE, taskID).From("depends_on").
+		Where("task_id = ?", otherID).Prefix("SELECT EXISTS (").Suffix(")").ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var exists bool
+	if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return exists, nil
+}
+
+// touch increments the version of the task that is not in the trash and returns it.
+func (t *Tasks) touch(ctx context.Context, id int) (*models.Task, error) {
+	sql, args, err := t.db.Builder.Update(tasksTable).
+		Set("updated_at", time.Now().UTC()).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("id = ?", id).
+		Where("deleted_at IS NULL").
+		Suffix("RETURNING " + strings.Join(taskColumns, ", ")).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	task, err := scanTask(t.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return task, nil
+}
@@ -0,0 +1,218 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"reflect"
+	"time"
+)
+
+// systemActor makes the changes that are not caused by a request, e.g. by background jobs.
+const systemActor = "system"
+
+// untrackedFields are not saved in the history: they change along with other fields or are derived from other data.
+var untrackedFields = map[string]bool{
+	"id":               true,
+	"version":          true,
+	"created_at":       true,
+	"updated_at":       true,
+	"reminder_sent_at": true,
+	"blocked":          true,
+	"comment_count":    true,
+}
+
+// lockTask returns the task, including one in the trash, and locks it until the end of the transaction.
+func (t *Tasks) lockTask(ctx context.Context, id int) (*models.Task, error) {
+	sql, args, err := t.db.Builder.Select(taskColumns...).From(tasksTable).
+		Where("id = ?", id).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	task, err := scanTask(t.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return task, nil
+}
+
+// advisoryLock takes the lock with the key until the end of the transaction.
+func (t *Tasks) advisoryLock(ctx context.Context, key string) error {
+	if _, err := t.db.Conn(ctx).Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+// lockTasks returns the tasks matching the condition by their ids and locks them until the end of the transaction.
+func (t *Tasks) lockTasks(ctx context.Context, where squirrel.Sqlizer) (map[int]*models.Task, error) {
+	sql, args, err := t.db.Builder.Select(taskColumns...).From(tasksTable).
+		Where(where).OrderBy("id").Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	tasks, err := t.queryTasks(ctx, sql, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	locked := make(map[int]*models.Task, len(tasks))
+	for _, task := range tasks {
+		locked[task.ID] = task
+	}
+	return locked, nil
+}
+
+// recordEvents saves updates of several tasks, before holds the tasks by their ids as lockTasks returns them.
+func (t *Tasks) recordEvents(ctx context.Context, before map[int]*models.Task, after []*models.Task) error {
+	for _, task := range after {
+		if err := t.recordEvent(ctx, models.TaskUpdated, before[task.ID], task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordEvent saves the change of the task in the history along with the snapshot of its new version,
+// it must be called in the transaction making the change. The request id and the actor are taken from the context.
+// Nil before means that the task has been created.
+func (t *Tasks) recordEvent(ctx context.Context, action models.TaskAction, before, after *models.Task) error {
+	if err := t.saveVersion(ctx, after); err != nil {
+		return err
+	}
+	changes, err := diffTasks(before, after)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 && action == models.TaskUpdated {
+		return nil
+	}
+	requestID, who := eventSource(ctx)
+	sql, args, err := t.db.Builder.Insert(eventsTable).
+		Columns("task_id", "action", "changes", "request_id", "actor", "created_at").
+		Values(after.ID, action, changes, requestID, who, time.Now().UTC()).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+// recordCreated saves creation of several tasks in the history along with the snapshots of their first versions,
+// the same way recordEvent does, copying all rows at once.
+func (t *Tasks) recordCreated(ctx context.Context, tasks []*models.Task) error {
+	createdAt := time.Now().UTC()
+	_, err := t.db.Conn(ctx).CopyFrom(ctx, pgx.Identifier{versionsTable},
+		[]string{"task_id", "version", "snapshot", "created_at"},
+		pgx.CopyFromSlice(len(tasks), func(i int) ([]any, error) {
+			return []any{tasks[i].ID, tasks[i].Version, tasks[i], createdAt}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+
+	requestID, who := eventSource(ctx)
+	_, err = t.db.Conn(ctx).CopyFrom(ctx, pgx.Identifier{eventsTable},
+		[]string{"task_id", "action", "changes", "request_id", "actor", "created_at"},
+		pgx.CopyFromSlice(len(tasks), func(i int) ([]any, error) {
+			changes, err := diffTasks(nil, tasks[i])
+			if err != nil {
+				return nil, err
+			}
+			return []any{tasks[i].ID, string(models.TaskCreated), changes, requestID, who, createdAt}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+// eventSource returns the request id and the actor of the change set by the service with models.WithEventSource.
+func eventSource(ctx context.Context) (string, string) {
+	source, _ := models.EventSourceFrom(ctx)
+	if source.Actor == "" {
+		source.Actor = systemActor
+	}
+	return source.RequestID, source.Actor
+}
+
+// History returns changes of the task from the oldest to the newest,
+// ErrNotFound is returned if the task has no history.
+func (t *Tasks) History(ctx context.Context, id int) ([]*models.TaskEvent, error) {
+	sql, args, err := t.db.Builder.Select("id", "task_id", "action", "changes", "request_id", "actor", "created_at").
+		From(eventsTable).Where("task_id = ?", id).OrderBy("id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+
+	events := make([]*models.TaskEvent, 0, 10)
+	for rows.Next() {
+		var event models.TaskEvent
+		err = rows.Scan(&event.ID, &event.TaskID, &event.Action, &event.Changes, &event.RequestID, &event.Actor, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		events = append(events, &event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	if len(events) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return events, nil
+}
+
+// diffTasks compares JSON representations of the tasks, so that the history shows the fields
+// the same way the API does.
+func diffTasks(before, after *models.Task) (map[string]models.FieldChange, error) {
+	from, err := taskFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := taskFields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := make(map[string]models.FieldChange)
+	for name, value := range to {
+		if !untrackedFields[name] && !reflect.DeepEqual(from[name], value) {
+			changes[name] = models.FieldChange{From: from[name], To: value}
+		}
+	}
+	for name, value := range from {
+		if _, ok := to[name]; !ok && !untrackedFields[name] {
+			changes[name] = models.FieldChange{From: value, To: nil}
+		}
+	}
+	return changes, nil
+}
+
+func taskFields(task *models.Task) (map[string]any, error) {
+	if task == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task: %w", err)
+	}
+	var fields map[string]any
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode task: %w", err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,136 @@
+package tasks
+
+import (
+	"context"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffTasks(t *testing.T) {
+	dueAt := time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC)
+	projectID := 3
+	task := func(change func(task *models.Task)) *models.Task {
+		task := &models.Task{
+			ID:        1,
+			Title:     "Buy milk",
+			Status:    models.StatusNew,
+			Priority:  models.PriorityHigh,
+			Position:  1024,
+			DueAt:     &dueAt,
+			CreatedAt: dueAt.Add(-time.Hour),
+			UpdatedAt: dueAt.Add(-time.Hour),
+			Version:   1,
+			Tags:      []string{"home"},
+			BlockedBy: []int{},
+		}
+		if change != nil {
+			change(task)
+		}
+		return task
+	}
+	tests := []struct {
+		name   string
+		before *models.Task
+		after  *models.Task
+		want   map[string]models.FieldChange
+	}{
+		{
+			name:  "created task",
+			after: task(nil),
+			want: map[string]models.FieldChange{
+				"title":      {To: "Buy milk"},
+				"status":     {To: "new"},
+				"priority":   {To: "high"},
+				"position":   {To: float64(1024)},
+				"due_at":     {To: "2025-05-01T18:00:00Z"},
+				"tags":       {To: []any{"home"}},
+				"blocked_by": {To: []any{}},
+			},
+		},
+		{
+			name:   "untracked fields are ignored",
+			before: task(nil),
+			after: task(func(task *models.Task) {
+				sentAt := dueAt
+				task.ReminderSentAt = &sentAt
+				task.UpdatedAt = dueAt
+				task.Version = 2
+				task.Blocked = true
+				task.CommentCount = 5
+			}),
+			want: map[string]models.FieldChange{},
+		},
+		{
+			name:   "changed fields",
+			before: task(nil),
+			after: task(func(task *models.Task) {
+				task.Title = "Buy oat milk"
+				task.Tags = []string{"home", "shop"}
+			}),
+			want: map[string]models.FieldChange{
+				"title": {From: "Buy milk", To: "Buy oat milk"},
+				"tags":  {From: []any{"home"}, To: []any{"home", "shop"}},
+			},
+		},
+		{
+			name:   "added and removed fields",
+			before: task(nil),
+			after: task(func(task *models.Task) {
+				task.ProjectID = &projectID
+				task.DueAt = nil
+			}),
+			want: map[string]models.FieldChange{
+				"project_id": {To: float64(3)},
+				"due_at":     {From: "2025-05-01T18:00:00Z"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffTasks(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("diffTasks() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventSource(t *testing.T) {
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		wantRequestID string
+		wantActor     string
+	}{
+		{
+			name:      "source isn't set",
+			ctx:       context.Background(),
+			wantActor: systemActor,
+		},
+		{
+			name:          "change without an actor",
+			ctx:           models.WithEventSource(context.Background(), models.EventSource{RequestID: "job"}),
+			wantRequestID: "job",
+			wantActor:     systemActor,
+		},
+		{
+			name:          "change made by the actor",
+			ctx:           models.WithEventSource(context.Background(), models.EventSource{RequestID: "request", Actor: "alice"}),
+			wantRequestID: "request",
+			wantActor:     "alice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestID, actor := eventSource(tt.ctx)
+			if requestID != tt.wantRequestID || actor != tt.wantActor {
+				t.Errorf("eventSource() = %q, %q, want %q, %q", requestID, actor, tt.wantRequestID, tt.wantActor)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"slices"
+)
+
+// subtreeCTE selects ids of the task and all of its descendants that are not in the trash.
+// path orders the tasks depth-first and guards against cycles in existing data.
+const subtreeCTE = `WITH RECURSIVE subtree (task_id, path) AS (
+	SELECT id, ARRAY[id] FROM tasks WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT t.id, s.path || t.id FROM tasks t JOIN subtree s ON t.parent_id = s.task_id
+	WHERE t.deleted_at IS NULL AND NOT t.id = ANY(s.path)
+)`
+
+// ancestorsCTE selects ids of the task and all of its ancestors, UNION stops on cycles.
+const ancestorsCTE = `WITH RECURSIVE ancestors (task_id, parent_id) AS (
+	SELECT id, parent_id FROM tasks WHERE id = ? AND deleted_at IS NULL
+	UNION
+	SELECT t.id, t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.parent_id
+)`
+
+// Children returns direct subtasks of the task.
+func (t *Tasks) Children(ctx context.Context, id int) ([]*models.Task, error) {
+	if _, err := t.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	sql, args, err := t.db.Builder.Select(taskColumns...).From(tasksTable).
+		Where("parent_id = ?", id).Where("deleted_at IS NULL").OrderBy("id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	return t.queryTasks(ctx, sql, args, 10)
+}
+
+// Subtree returns the task followed by all of its descendants in depth-first order.
+func (t *Tasks) Subtree(ctx context.Context, id int) ([]*models.Task, error) {
+	sql, args, err := t.db.Builder.Select(taskColumns...).Prefix(subtreeCTE, id).From(tasksTable).
+		Join("subtree ON subtree.task_id = tasks.id").OrderBy("subtree.path").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	tasks, err := t.queryTasks(ctx, sql, args, 10)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return tasks, nil
+}
+
+// Ancestors returns ids of the task and all of its ancestors.
+// ErrNotFound is returned if the task doesn't exist or is in the trash.
+func (t *Tasks) Ancestors(ctx context.Context, id int) ([]int, error) {
+	sql, args, err := t.db.Builder.Select("task_id").Prefix(ancestorsCTE, id).From("ancestors").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0, 4)
+	for rows.Next() {
+		var ancestor int
+		if err := rows.Scan(&ancestor); err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		ids = append(ids, ancestor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	if len(ids) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return ids, nil
+}
+
+// checkParent makes sure that the parent exists and the task is not the parent itself or one of its ancestors,
+// ErrParentNotFound or ErrCycle is returned otherwise. Changes of parents are serialized until the end
+// of the transaction, so that concurrent changes can't make a cycle together.
+func (t *Tasks) checkParent(ctx context.Context, id int, parentID int) error {
+	if err := t.advisoryLock(ctx, "tasks.parent_id"); err != nil {
+		return err
+	}
+	ancestors, err := t.Ancestors(ctx, parentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: task %d", repository.ErrParentNotFound, parentID)
+	} else if err != nil {
+		return err
+	}
+	if slices.Contains(ancestors, id) {
+		return fmt.Errorf("%w: task %d is task %d or one of its subtasks", repository.ErrCycle, parentID, id)
+	}
+	return nil
+}
+
+// CountOpenDescendants returns the number of descendants of the task that are not in terminal statuses.
+func (t *Tasks) CountOpenDescendants(ctx context.Context, id int) (int, error) {
+	sql, args, err := t.db.Builder.Select("COUNT(*)").Prefix(subtreeCTE, id).From(tasksTable).
+		Join("subtree ON subtree.task_id = tasks.id").
+		Where("tasks.id <> ?", id).Where("tasks.status NOT IN " + terminalStatuses).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var count int
+	if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,185 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// Import inserts the tasks with COPY in one transaction and returns them in the same order. Tasks are put
+// at the end of their status columns in the given order, empty status is replaced with the first status
+// of the board. Projects and parent tasks must exist, otherwise nothing is inserted.
+func (t *Tasks) Import(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	createdAt := time.Now().UTC()
+	var res []*models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		// COPY can't return generated ids, so they are taken from the sequence beforehand
+		sql, args, err := t.db.Builder.Select("nextval(pg_get_serial_sequence('tasks', 'id'))").
+			From(fmt.Sprintf("generate_series(1, %d)", len(tasks))).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+
+		statuses, positions, err := t.importPositions(ctx, tasks)
+		if err != nil {
+			return err
+		}
+		_, err = t.db.Conn(ctx).CopyFrom(ctx, pgx.Identifier{tasksTable},
+			[]string{"id", "title", "description", "status", "priority", "position", "project_id", "parent_id", "recurrence", "series_id", "due_at", "reminder_at", "created_at", "updated_at", "version"},
+			pgx.CopyFromSlice(len(tasks), func(i int) ([]any, error) {
+				task := tasks[i]
+				var seriesID *int
+				if task.Recurrence != "" {
+					seriesID = &ids[i]
+				}
+				return []any{ids[i], task.Title, task.Description, statuses[i], int16(task.Priority), positions[i], task.ProjectID, task.ParentID, task.Recurrence, seriesID, task.DueAt, task.ReminderAt, createdAt, createdAt, 1}, nil
+			}))
+		if err != nil {
+			return mapWriteError(err)
+		}
+		if err = t.importTags(ctx, ids, tasks); err != nil {
+			return err
+		}
+
+		sql, args, err = t.db.Builder.Select(taskColumns...).From(tasksTable).
+			Where(squirrel.Eq{"id": ids}).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		imported, err := t.queryTasks(ctx, sql, args, len(ids))
+		if err != nil {
+			return err
+		}
+		byID := make(map[int]*models.Task, len(imported))
+		for _, task := range imported {
+			byID[task.ID] = task
+		}
+		res = make([]*models.Task, len(ids))
+		for i, id := range ids {
+			res[i] = byID[id]
+		}
+		return t.recordCreated(ctx, res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// importPositions returns statuses of the imported tasks and their positions after the tasks already in the columns.
+func (t *Tasks) importPositions(ctx context.Context, tasks []*models.Task) ([]string, []int64, error) {
+	first, err := t.firstStatus(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	statuses := make([]string, len(tasks))
+	ends := make(map[string]int64)
+	for i, task := range tasks {
+		statuses[i] = task.Status
+		if statuses[i] == "" {
+			statuses[i] = first
+		}
+		ends[statuses[i]] = 0
+	}
+
+	names := make([]string, 0, len(ends))
+	for name := range ends {
+		names = append(names, name)
+	}
+	if err = t.lockColumns(ctx, names...); err != nil {
+		return nil, nil, err
+	}
+	sql, args, err := t.db.Builder.Select("status", "MAX(position)").From(tasksTable).
+		Where(squirrel.Eq{"status": names}).Where("deleted_at IS NULL").GroupBy("status").ToSql()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var end int64
+		if err = rows.Scan(&status, &end); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		ends[status] = end
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+
+	positions := make([]int64, len(tasks))
+	for i, status := range statuses {
+		ends[status] += positionGap
+		positions[i] = ends[status]
+	}
+	return statuses, positions, nil
+}
+
+// importTags creates the tags of the imported tasks and copies the links between them.
+func (t *Tasks) importTags(ctx context.Context, ids []int, tasks []*models.Task) error {
+	seen := make(map[string]bool)
+	var names []string
+	for _, task := range tasks {
+		for _, name := range task.Tags {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if err := t.createTags(ctx, names); err != nil {
+		return err
+	}
+
+	sql, args, err := t.db.Builder.Select("id", "name").From(tagsTable).Where(squirrel.Eq{"name": names}).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+	tagIDs := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		tagIDs[name] = id
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+
+	var links [][]any
+	for i, task := range tasks {
+		for _, name := range task.Tags {
+			links = append(links, []any{ids[i], tagIDs[name]})
+		}
+	}
+	if _, err = t.db.Conn(ctx).CopyFrom(ctx, pgx.Identifier{taskTagsTable}, []string{"task_id", "tag_id"}, pgx.CopyFromRows(links)); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
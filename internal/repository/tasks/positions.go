@@ -0,0 +1,272 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"slices"
+	"strings"
+	"time"
+)
+
+// positionGap is the distance between neighbouring tasks after rebalancing,
+// so that about 16 tasks can be put between any two of them before the next rebalancing.
+const positionGap int64 = 1 << 16
+
+// errNoGap means that there is no free position between the neighbours and the column must be rebalanced.
+var errNoGap = errors.New("no gap between positions")
+
+// endOfColumn returns an expression of the position after all tasks of the status except the given one.
+// The column must be locked with lockColumn.
+func endOfColumn(status string, exceptID int) squirrel.Sqlizer {
+	return squirrel.Expr("(SELECT COALESCE(MAX(p.position), 0) + ? FROM tasks p WHERE p.status = ? AND p.id <> ? AND p.deleted_at IS NULL)",
+		positionGap, status, exceptID)
+}
+
+// lockColumn serializes changes of positions in the status column until the end of the transaction,
+// otherwise concurrent changes could take the same position.
+func (t *Tasks) lockColumn(ctx context.Context, status string) error {
+	return t.advisoryLock(ctx, "tasks.position:"+status)
+}
+
+// lockColumns locks the status columns in the order of their names, so that transactions locking several
+// columns can't deadlock. Columns must be locked before the tasks in them, because rebalance updates tasks
+// of the column while holding its lock.
+func (t *Tasks) lockColumns(ctx context.Context, statuses ...string) error {
+	for _, status := range columnLockOrder(statuses) {
+		if err := t.lockColumn(ctx, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnLockOrder returns the distinct non-empty statuses in the order their columns are locked.
+func columnLockOrder(statuses []string) []string {
+	res := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		if status != "" {
+			res = append(res, status)
+		}
+	}
+	slices.Sort(res)
+	return slices.Compact(res)
+}
+
+// occurrenceColumn returns the column the next occurrence of the task is created in when the status of the task
+// changes, empty for tasks that don't recur. recurrence replaces the rule of the task if not nil.
+// The occurrence is created after the task has been locked, so its column is locked along with the target one.
+func (t *Tasks) occurrenceColumn(ctx context.Context, task *models.Task, recurrence *string) (string, error) {
+	rule := task.Recurrence
+	if recurrence != nil {
+		rule = *recurrence
+	}
+	if rule == "" {
+		return "", nil
+	}
+	return t.firstStatus(ctx)
+}
+
+// Move puts the task between its new neighbours, rebalancing positions of the column if there is no gap between them.
+// ErrInvalidInput is returned if a neighbour is not in the target column or the neighbours are in the wrong order.
+// ErrConcurrentUpdate is returned if the status or the recurrence of the task changes while it is being locked.
+func (t *Tasks) Move(ctx context.Context, move *models.TaskMove) (*models.Task, error) {
+	var task *models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		task, err = t.move(ctx, move)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (t *Tasks) move(ctx context.Context, move *models.TaskMove) (*models.Task, error) {
+	// the columns are locked before the task, see lockColumns, so they are chosen by the task read without a lock
+	current, err := t.GetByID(ctx, move.ID)
+	if err != nil {
+		return nil, err
+	}
+	status := move.Status
+	if status == "" {
+		status = current.Status
+	}
+	var occurrence string
+	if status != current.Status {
+		if occurrence, err = t.occurrenceColumn(ctx, current, nil); err != nil {
+			return nil, err
+		}
+	}
+	if err = t.lockColumns(ctx, status, occurrence); err != nil {
+		return nil, err
+	}
+	before, err := t.lockTask(ctx, move.ID)
+	if err != nil {
+		return nil, err
+	}
+	if before.DeletedAt != nil {
+		return nil, repository.ErrNotFound
+	}
+	version := before.Version
+	if move.Version != 0 && move.Version != version {
+		return nil, repository.ErrVersionMismatch
+	}
+	if before.Status != current.Status || before.Recurrence != current.Recurrence {
+		// the locked columns may be wrong and the right ones can't be locked after the task
+		return nil, repository.ErrConcurrentUpdate
+	}
+	statusChanged := status != before.Status
+	if (move.AfterID != nil && *move.AfterID == move.ID) || (move.BeforeID != nil && *move.BeforeID == move.ID) {
+		return nil, fmt.Errorf("%w: task can't be its own neighbour", repository.ErrInvalidInput)
+	}
+
+	position, err := t.newPosition(ctx, move, status)
+	if errors.Is(err, errNoGap) {
+		if err = t.rebalance(ctx, status, move.ID); err != nil {
+			return nil, err
+		}
+		position, err = t.newPosition(ctx, move, status)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := t.db.Builder.Update(tasksTable).
+		Set("position", position).
+		Set("updated_at", time.Now().UTC()).
+		Set("version", version+1).
+		Where("id = ?", move.ID).
+		Suffix("RETURNING " + strings.Join(taskColumns, ", "))
+	if statusChanged {
+		query = query.Set("status", status).Set("status_reason", move.StatusReason)
+	}
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	task, err := scanTask(t.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		return nil, mapWriteError(err)
+	}
+	if err = t.recordEvent(ctx, models.TaskUpdated, before, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// newPosition returns the position between the neighbours of the move in the status column.
+func (t *Tasks) newPosition(ctx context.Context, move *models.TaskMove, status string) (int64, error) {
+	var lower, upper *int64
+	var err error
+	if move.AfterID != nil {
+		if lower, err = t.neighbourPosition(ctx, *move.AfterID, status); err != nil {
+			return 0, err
+		}
+	}
+	if move.BeforeID != nil {
+		if upper, err = t.neighbourPosition(ctx, *move.BeforeID, status); err != nil {
+			return 0, err
+		}
+	}
+	switch {
+	case lower != nil && upper != nil:
+		if *upper < *lower {
+			return 0, fmt.Errorf("%w: task %d is after task %d", repository.ErrInvalidInput, *move.BeforeID, *move.AfterID)
+		}
+	case lower != nil:
+		if upper, err = t.adjacentPosition(ctx, move.ID, status, *lower, false); err != nil {
+			return 0, err
+		}
+	case upper != nil:
+		if lower, err = t.adjacentPosition(ctx, move.ID, status, *upper, true); err != nil {
+			return 0, err
+		}
+	default:
+		if lower, err = t.adjacentPosition(ctx, move.ID, status, 0, true); err != nil {
+			return 0, err
+		}
+		if lower == nil {
+			return positionGap, nil
+		}
+		return *lower + positionGap, nil
+	}
+
+	switch {
+	case lower == nil:
+		return *upper - positionGap, nil
+	case upper == nil:
+		return *lower + positionGap, nil
+	case *upper-*lower < 2:
+		return 0, errNoGap
+	}
+	return *lower + (*upper-*lower)/2, nil
+}
+
+// neighbourPosition returns the position of the task, which must be in the status column.
+func (t *Tasks) neighbourPosition(ctx context.Context, id int, status string) (*int64, error) {
+	sql, args, err := t.db.Builder.Select("position").From(tasksTable).
+		Where("id = ?", id).Where("status = ?", status).Where("deleted_at IS NULL").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var position int64
+	err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&position)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: task %d is not in the '%s' column", repository.ErrInvalidInput, id, status)
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return &position, nil
+}
+
+// adjacentPosition returns the closest position below or above the given one in the column
+// ignoring the moved task, nil means there is no such position. Zero position with below set
+// looks for the last position of the column.
+func (t *Tasks) adjacentPosition(ctx context.Context, movedID int, status string, position int64, below bool) (*int64, error) {
+	query := t.db.Builder.Select().From(tasksTable).
+		Where("status = ?", status).Where("id <> ?", movedID).Where("deleted_at IS NULL")
+	switch {
+	case below && position == 0:
+		query = query.Column("MAX(position)")
+	case below:
+		query = query.Column("MAX(position)").Where("position < ?", position)
+	default:
+		query = query.Column("MIN(position)").Where("position > ?", position)
+	}
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var res *int64
+	if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&res); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return res, nil
+}
+
+// rebalance spreads positions of the column evenly keeping the order of tasks, the moved task is left out
+// because it gets its position right after. The order of the tasks stays the same, so neither their versions
+// nor the history are changed and their ETags stay valid.
+func (t *Tasks) rebalance(ctx context.Context, status string, movedID int) error {
+	inColumn := squirrel.And{squirrel.Eq{"status": status}, squirrel.NotEq{"id": movedID}, squirrel.Expr("deleted_at IS NULL")}
+	sql, args, err := t.db.Builder.Update(tasksTable).
+		Set("position", squirrel.Expr("ordered.rn * ?", positionGap)).
+		FromSelect(t.db.Builder.Select("id AS ordered_id", "row_number() OVER (ORDER BY position, id) AS rn").
+			From(tasksTable).Where(inColumn), "ordered").
+		Where("tasks.id = ordered.ordered_id").
+		Where("tasks.position <> ordered.rn * ?", positionGap).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
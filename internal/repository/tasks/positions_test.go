@@ -0,0 +1,42 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColumnLockOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     []string
+	}{
+		{
+			name: "no columns",
+			want: []string{},
+		},
+		{
+			name:     "columns are locked by name",
+			statuses: []string{"new", "done", "in_progress"},
+			want:     []string{"done", "in_progress", "new"},
+		},
+		{
+			name:     "column is locked once",
+			statuses: []string{"new", "done", "new"},
+			want:     []string{"done", "new"},
+		},
+		{
+			name:     "task that doesn't recur has no occurrence column",
+			statuses: []string{"done", ""},
+			want:     []string{"done"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the order must not depend on the order of the arguments, otherwise two transactions could deadlock
+			if got := columnLockOrder(tt.statuses); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columnLockOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
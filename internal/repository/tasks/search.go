@@ -0,0 +1,116 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"strings"
+)
+
+// Matched words are selected with characters of the private use area, which are turned into <mark> tags
+// after the text is HTML-escaped. The characters are removed from the text beforehand.
+const (
+	selStart = "\ue000"
+	selStop  = "\ue001"
+)
+
+// headlineOptions select matched words and keep highlights short.
+const headlineOptions = "StartSel=\"" + selStart + "\", StopSel=\"" + selStop + "\", MaxWords=35, MinWords=15, MaxFragments=3, FragmentDelimiter=\" ... \""
+
+// highlighter escapes the headline the same way as html.EscapeString and wraps the selected words in <mark> tags.
+var highlighter = strings.NewReplacer(`&`, "&amp;", `'`, "&#39;", `<`, "&lt;", `>`, "&gt;", `"`, "&#34;",
+	selStart, "<mark>", selStop, "</mark>")
+
+// Search returns tasks matching the query ordered by rank, tasks in the trash are not searched.
+func (t *Tasks) Search(ctx context.Context, query *models.SearchQuery) (*models.SearchPage, error) {
+	sql, args, err := t.db.Builder.Select(taskColumns...).
+		Prefix("WITH q AS (SELECT websearch_to_tsquery(?::regconfig, ?) AS query)", query.Language, query.Query).
+		Column("ts_rank_cd(search_vector, q.query) AS rank").
+		Column("ts_headline(search_language, translate(title, ?, ''), q.query, ?)", selStart+selStop, headlineOptions).
+		Column("ts_headline(search_language, translate(COALESCE(description, ''), ?, ''), q.query, ?)", selStart+selStop, headlineOptions).
+		Column("COUNT(*) OVER () AS total").
+		From(tasksTable+" CROSS JOIN q").
+		Where("search_vector @@ q.query").
+		Where("deleted_at IS NULL").
+		OrderBy("rank DESC", "id").
+		Limit(uint64(query.Limit)).Offset(uint64(query.Offset)).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	defer rows.Close()
+
+	page := &models.SearchPage{Results: make([]*models.SearchResult, 0, query.Limit)}
+	for rows.Next() {
+		var res models.SearchResult
+		res.Task, err = scanTask(withColumns{Row: rows, extra: []any{&res.Rank, &res.TitleHighlight, &res.DescriptionHighlight, &page.Total}})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		res.TitleHighlight = highlighter.Replace(res.TitleHighlight)
+		res.DescriptionHighlight = highlighter.Replace(res.DescriptionHighlight)
+		page.Results = append(page.Results, &res)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	return page, nil
+}
+
+// withColumns scans columns selected after taskColumns into the extra destinations.
+type withColumns struct {
+	pgx.Row
+	extra []any
+}
+
+func (r withColumns) Scan(dest ...any) error {
+	return r.Row.Scan(append(dest, r.extra...)...)
+}
+
+// UseSearchLanguage makes the text search configuration the default one for new tasks and reindexes the tasks
+// indexed with another configuration, which happens only after the configuration has been changed.
+func (t *Tasks) UseSearchLanguage(ctx context.Context, language string) error {
+	return t.db.WithTx(ctx, func(ctx context.Context) error {
+		// the cast fails if there is no such configuration
+		var name string
+		if err := t.db.Conn(ctx).QueryRow(ctx, "SELECT $1::regconfig::text", language).Scan(&name); err != nil {
+			return fmt.Errorf("%w: unknown text search configuration '%s': %s", repository.ErrInvalidInput, language, err)
+		}
+		literal := "'" + strings.ReplaceAll(name, "'", "''") + "'::regconfig"
+
+		sql, args, err := t.db.Builder.Select("column_default").From("information_schema.columns").
+			Where("table_schema = current_schema()").
+			Where("table_name = ?", tasksTable).
+			Where("column_name = ?", "search_language").ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		var current string
+		if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&current); err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+		if current != literal {
+			// DDL has no parameters, the name is quoted as a literal
+			if _, err = t.db.Conn(ctx).Exec(ctx, "ALTER TABLE tasks ALTER COLUMN search_language SET DEFAULT "+literal); err != nil {
+				return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+			}
+		}
+
+		sql, args, err = t.db.Builder.Update(tasksTable).
+			Set("search_language", squirrel.Expr("?::regconfig", name)).
+			Where("search_language <> ?::regconfig", name).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,147 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"strings"
+	"time"
+)
+
+// startSeries makes the task the first occurrence of its own series.
+func (t *Tasks) startSeries(ctx context.Context, id int) error {
+	sql, args, err := t.db.Builder.Update(tasksTable).
+		Set("series_id", squirrel.Expr("id")).
+		Where("id = ?", id).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+// Series returns occurrences of the series that are not in the trash, ordered by due date.
+func (t *Tasks) Series(ctx context.Context, seriesID int) ([]*models.Task, error) {
+	sql, args, err := t.db.Builder.Select(taskColumns...).From(tasksTable).
+		Where("series_id = ?", seriesID).Where("deleted_at IS NULL").
+		OrderBy("due_at NULLS FIRST", "id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	tasks, err := t.queryTasks(ctx, sql, args, 10)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return tasks, nil
+}
+
+// HasOpenOccurrence reports whether the series has an occurrence that is not done yet. The series is locked
+// until the end of the transaction, so that concurrent callers creating the next occurrence see each other's one.
+func (t *Tasks) HasOpenOccurrence(ctx context.Context, seriesID int) (bool, error) {
+	if err := t.advisoryLock(ctx, fmt.Sprintf("tasks.series:%d", seriesID)); err != nil {
+		return false, err
+	}
+	sql, args, err := t.db.Builder.Select("1").From(tasksTable).
+		Where("series_id = ?", seriesID).
+		Where("status NOT IN " + terminalStatuses).
+		Where("deleted_at IS NULL").
+		Prefix("SELECT EXISTS (").Suffix(")").ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var exists bool
+	if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return exists, nil
+}
+
+// UpdateSeries applies the patch to the occurrences of the series that are not done yet
+// and returns them. Done occurrences are kept as they were.
+func (t *Tasks) UpdateSeries(ctx context.Context, seriesID int, patch *models.SeriesPatch) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := t.Series(ctx, seriesID); err != nil {
+			return err
+		}
+		open := squirrel.And{
+			squirrel.Eq{"series_id": seriesID},
+			squirrel.Expr("status NOT IN " + terminalStatuses),
+			squirrel.Eq{"deleted_at": nil},
+		}
+		before, err := t.lockTasks(ctx, open)
+		if err != nil {
+			return err
+		}
+		query := t.db.Builder.Update(tasksTable).
+			Set("updated_at", time.Now().UTC()).
+			Set("version", squirrel.Expr("version + 1")).
+			Where(open)
+		if patch.Title != nil {
+			query = query.Set("title", *patch.Title)
+		}
+		if patch.Description != nil {
+			query = query.Set("description", *patch.Description)
+		}
+		if patch.Priority != nil {
+			query = query.Set("priority", int16(*patch.Priority))
+		}
+		if patch.Recurrence != nil {
+			query = query.Set("recurrence", *patch.Recurrence)
+		}
+		sql, args, err := query.Suffix("RETURNING " + strings.Join(taskColumns, ", ")).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		if tasks, err = t.queryTasks(ctx, sql, args, len(before)); err != nil {
+			return err
+		}
+		return t.recordEvents(ctx, before, tasks)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// StopSeries removes the recurrence from all occurrences of the series including the ones
+// in the trash, so that no more occurrences are created. The series itself is kept.
+func (t *Tasks) StopSeries(ctx context.Context, seriesID int) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		recurring := squirrel.And{squirrel.Eq{"series_id": seriesID}, squirrel.NotEq{"recurrence": ""}}
+		before, err := t.lockTasks(ctx, recurring)
+		if err != nil {
+			return err
+		}
+		sql, args, err := t.db.Builder.Update(tasksTable).
+			Set("recurrence", "").
+			Set("updated_at", time.Now().UTC()).
+			Set("version", squirrel.Expr("version + 1")).
+			Where(recurring).
+			Suffix("RETURNING " + strings.Join(taskColumns, ", ")).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		stopped, err := t.queryTasks(ctx, sql, args, len(before))
+		if err != nil {
+			return err
+		}
+		if err = t.recordEvents(ctx, before, stopped); err != nil {
+			return err
+		}
+		tasks, err = t.Series(ctx, seriesID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
@@ -4,19 +4,48 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/Masterminds/squirrel"
 	"github.com/igorgrichanov/toDoList/internal/models"
 	"github.com/igorgrichanov/toDoList/internal/repository"
 	"github.com/igorgrichanov/toDoList/pkg/postgres"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"log/slog"
+	"strings"
 	"time"
 )
 
 const (
-	tasksTable = "tasks"
+	tasksTable        = "tasks"
+	tagsTable         = "tags"
+	taskTagsTable     = "task_tags"
+	dependenciesTable = "task_dependencies"
+	projectsTable     = "projects"
+	statusesTable     = "statuses"
+	eventsTable       = "task_events"
 )
 
+// tagsColumn aggregates names of the task tags into a sorted array.
+const tagsColumn = `COALESCE((SELECT array_agg(tg.name ORDER BY tg.name) FROM task_tags tt
+	JOIN tags tg ON tg.id = tt.tag_id WHERE tt.task_id = tasks.id), '{}') AS tags`
+
+// blockedByColumn aggregates ids of the tasks the task depends on.
+const blockedByColumn = `COALESCE((SELECT array_agg(d.blocked_by_id ORDER BY d.blocked_by_id) FROM task_dependencies d
+	WHERE d.task_id = tasks.id), '{}') AS blocked_by`
+
+// terminalStatuses selects names of the statuses of finished tasks.
+const terminalStatuses = "(SELECT name FROM statuses WHERE terminal)"
+
+// blockedColumn tells whether some of the tasks the task depends on are not finished, tasks in the trash are ignored.
+const blockedColumn = `EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks b ON b.id = d.blocked_by_id
+	WHERE d.task_id = tasks.id AND b.status NOT IN ` + terminalStatuses + ` AND b.deleted_at IS NULL) AS blocked`
+
+// commentCountColumn counts comments of the task.
+const commentCountColumn = `(SELECT COUNT(*) FROM comments c WHERE c.task_id = tasks.id) AS comment_count`
+
+// taskColumns is the list of columns scanned by scanTask, in the same order.
+var taskColumns = []string{"id", "title", "description", "status", "status_reason", "priority", "position", "project_id", "parent_id", "recurrence", "series_id", "due_at", "reminder_at", "reminder_sent_at", "created_at", "updated_at", "version", "deleted_at", tagsColumn, blockedByColumn, blockedColumn, commentCountColumn}
+
 type Tasks struct {
 	log *slog.Logger
 	db  *postgres.Postgres
@@ -26,125 +55,583 @@ func NewTasksRepository(log *slog.Logger, db *postgres.Postgres) *Tasks {
 	return &Tasks{log: log, db: db}
 }
 
+// InTx runs fn in a transaction, calls of the repository made with the context passed to fn use it.
+// Nested calls run in savepoints, so that a failed call can be rolled back without the outer transaction.
+func (t *Tasks) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.db.WithTx(ctx, fn)
+}
+
+// Create inserts the task at the end of its status column,
+// empty status is replaced with the first status of the board.
 func (t *Tasks) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
 	createdAt := time.Now().UTC()
 	updatedAt := createdAt
-	sql, args, err := t.db.Builder.Insert(tasksTable).
-		Columns("title", "description", "status", "created_at", "updated_at").
-		Values(task.Title, task.Description, task.Status, createdAt, updatedAt).
-		Suffix("RETURNING \"id\"").ToSql()
+	var res *models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		status := task.Status
+		if status == "" {
+			var err error
+			if status, err = t.firstStatus(ctx); err != nil {
+				return err
+			}
+		}
+		if err := t.lockColumn(ctx, status); err != nil {
+			return err
+		}
+		sql, args, err := t.db.Builder.Insert(tasksTable).
+			Columns("title", "description", "status", "priority", "position", "project_id", "parent_id", "recurrence", "series_id", "due_at", "reminder_at", "created_at", "updated_at", "version").
+			Values(task.Title, task.Description, status, int16(task.Priority), endOfColumn(status, 0), task.ProjectID, task.ParentID, task.Recurrence, task.SeriesID, task.DueAt, task.ReminderAt, createdAt, updatedAt, 1).
+			Suffix("RETURNING \"id\"").ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		var id int
+		err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+		if err != nil {
+			return mapWriteError(err)
+		}
+		if task.Recurrence != "" && task.SeriesID == nil {
+			if err = t.startSeries(ctx, id); err != nil {
+				return err
+			}
+		}
+		if err = t.setTags(ctx, id, task.Tags); err != nil {
+			return err
+		}
+		if res, err = t.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return t.recordEvent(ctx, models.TaskCreated, nil, res)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		return nil, err
 	}
-	var insertedID int
-	err = t.db.Pool.QueryRow(ctx, sql, args...).Scan(&insertedID)
+	return res, nil
+}
+
+// firstStatus returns the status new tasks get by default.
+func (t *Tasks) firstStatus(ctx context.Context) (string, error) {
+	sql, args, err := t.db.Builder.Select("name").From(statusesTable).OrderBy("position", "name").Limit(1).ToSql()
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23502": // not null violation
-				return nil, fmt.Errorf("%w: missing required field", repository.ErrInvalidInput)
-			case "23514": // check constraint
-				return nil, fmt.Errorf("%w: invalid value in field with CHECK", repository.ErrInvalidInput)
-			}
+		return "", fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var status string
+	if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&status); err != nil {
+		return "", fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return status, nil
+}
+
+func (t *Tasks) List(ctx context.Context, filter *models.ListFilter, page *models.Page) (*models.TaskPage, error) {
+	if filter.ProjectID != nil {
+		if err := t.projectExists(ctx, *filter.ProjectID); err != nil {
+			return nil, err
 		}
+	}
+	sql, args, err := applyFilter(t.db.Builder.Select("COUNT(*)").From(tasksTable), filter).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var total int
+	if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
 	}
-	task.ID = insertedID
-	task.CreatedAt = createdAt
-	task.UpdatedAt = updatedAt
-	return task, nil
-}
 
-func (t *Tasks) List(ctx context.Context) ([]*models.Task, error) {
+	sort, err := normalizeSort(page.Sort)
+	if err != nil {
+		return nil, err
+	}
 	// if table grows, SELECT "*" may cause errors when retrieving data
-	sql, args, err := t.db.Builder.Select("id", "title", "description", "status", "created_at", "updated_at").
-		From(tasksTable).OrderBy("id").ToSql()
+	// one extra row is requested to find out whether there is a next page
+	query := applyFilter(t.db.Builder.Select(taskColumns...).From(tasksTable), filter).
+		OrderBy(orderBy(sort)...).Limit(uint64(page.Limit + 1))
+	if page.After != "" {
+		values, err := decodeCursor(page.After, sort)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(afterCursor(sort, values))
+	} else if page.Offset > 0 {
+		query = query.Offset(uint64(page.Offset))
+	}
+	sql, args, err = query.ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
 	}
-	rows, err := t.db.Pool.Query(ctx, sql, args...)
+	tasks, err := t.queryTasks(ctx, sql, args, page.Limit+1)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	tasks := make([]*models.Task, 0, 20) // Можно отправить select count(1) для cap, но это лишнее обращение в БД.
-	// Для больших таблиц используют пагинацию
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt)
+	res := &models.TaskPage{Tasks: tasks, Total: total}
+	if len(tasks) > page.Limit {
+		res.Tasks = tasks[:page.Limit]
+		res.NextCursor, err = encodeCursor(sort, res.Tasks[len(res.Tasks)-1])
 		if err != nil {
 			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
 		}
-		tasks = append(tasks, &task)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
 	}
-	return tasks, nil
+	return res, nil
 }
 
-func (t *Tasks) Update(ctx context.Context, task *models.Task) error {
-	// get last update time to avoid data races
-	var lastUpdatedAt time.Time
-	sql, args, err := t.db.Builder.Select("updated_at").From(tasksTable).
-		Where("id = ?", task.ID).ToSql()
+func (t *Tasks) GetByID(ctx context.Context, id int) (*models.Task, error) {
+	sql, args, err := t.db.Builder.Select(taskColumns...).From(tasksTable).
+		Where("id = ?", id).Where("deleted_at IS NULL").ToSql()
 	if err != nil {
-		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
 	}
-	err = t.db.Pool.QueryRow(ctx, sql, args...).Scan(&lastUpdatedAt)
+	task, err := scanTask(t.db.Conn(ctx).QueryRow(ctx, sql, args...))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return repository.ErrNotFound
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return task, nil
+}
+
+// Update sets the columns supplied in the patch and returns the updated task.
+// If patch.Version is set and differs from the current one, ErrVersionMismatch is returned.
+// ErrParentNotFound and ErrCycle are returned if the new parent doesn't exist or is one of the subtasks.
+// ErrConcurrentUpdate is returned if the status or the recurrence of the task changes while it is being locked.
+func (t *Tasks) Update(ctx context.Context, patch *models.TaskPatch) (*models.Task, error) {
+	var task *models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		task, err = t.update(ctx, patch)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (t *Tasks) update(ctx context.Context, patch *models.TaskPatch) (*models.Task, error) {
+	// the columns a status change writes to are locked before the task as in move,
+	// so they are chosen by the task read without a lock
+	var current *models.Task
+	if patch.Status != nil {
+		var err error
+		if current, err = t.GetByID(ctx, patch.ID); err != nil {
+			return nil, err
+		}
+		if *patch.Status != current.Status {
+			occurrence, err := t.occurrenceColumn(ctx, current, patch.Recurrence)
+			if err != nil {
+				return nil, err
+			}
+			if err = t.lockColumns(ctx, *patch.Status, occurrence); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// the task is locked to avoid data races, its current state goes to the history
+	before, err := t.lockTask(ctx, patch.ID)
+	if err != nil {
+		return nil, err
+	}
+	if before.DeletedAt != nil {
+		return nil, repository.ErrNotFound
+	}
+	version := before.Version
+	if patch.Version != 0 && patch.Version != version {
+		return nil, repository.ErrVersionMismatch
+	}
+	if current != nil && (before.Status != current.Status || before.Recurrence != current.Recurrence) {
+		// the locked columns may be wrong and the right ones can't be locked after the task
+		return nil, repository.ErrConcurrentUpdate
+	}
+	if patch.ParentID.Value != nil {
+		if err = t.checkParent(ctx, patch.ID, *patch.ParentID.Value); err != nil {
+			return nil, err
+		}
+	}
+	// tags are changed first, so that the task returned below contains them
+	if patch.Tags != nil {
+		if err = t.setTags(ctx, patch.ID, patch.Tags); err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
 	}
 
 	updatedAt := time.Now().UTC()
-	sql, args, err = t.db.Builder.Update(tasksTable).
-		Set("title", task.Title).
-		Set("description", task.Description).
-		Set("status", task.Status).
+	query := t.db.Builder.Update(tasksTable).
 		Set("updated_at", updatedAt).
-		Where("id = ?", task.ID).
-		Where("updated_at = ?", lastUpdatedAt).ToSql()
+		Set("version", version+1).
+		Where("id = ?", patch.ID).
+		Where("version = ?", version)
+	if patch.Title != nil {
+		query = query.Set("title", *patch.Title)
+	}
+	if patch.Description != nil {
+		query = query.Set("description", *patch.Description)
+	}
+	if patch.Status != nil {
+		// a task moved to another status goes to the end of its column
+		query = query.Set("status", *patch.Status).
+			Set("position", squirrel.Expr("CASE WHEN status = ? THEN position ELSE ? END", *patch.Status, endOfColumn(*patch.Status, patch.ID)))
+	}
+	if patch.StatusReason != nil {
+		query = query.Set("status_reason", *patch.StatusReason)
+	}
+	if patch.Priority != nil {
+		query = query.Set("priority", int16(*patch.Priority))
+	}
+	if patch.ProjectID.Set {
+		query = query.Set("project_id", patch.ProjectID.Value)
+	}
+	if patch.ParentID.Set {
+		query = query.Set("parent_id", patch.ParentID.Value)
+	}
+	if patch.DueAt.Set {
+		query = query.Set("due_at", patch.DueAt.Value)
+	}
+	if patch.ReminderAt.Set {
+		// the reminder is sent again only if its time changes
+		query = query.Set("reminder_at", patch.ReminderAt.Value).
+			Set("reminder_sent_at", squirrel.Expr("CASE WHEN reminder_at IS NOT DISTINCT FROM ?::timestamptz THEN reminder_sent_at END", patch.ReminderAt.Value))
+	}
+	if patch.Recurrence != nil {
+		query = query.Set("recurrence", *patch.Recurrence)
+		if *patch.Recurrence != "" {
+			// a task that becomes recurring starts its own series
+			query = query.Set("series_id", squirrel.Expr("COALESCE(series_id, id)"))
+		}
+	}
+	sql, args, err := query.Suffix("RETURNING " + strings.Join(taskColumns, ", ")).ToSql()
 	if err != nil {
-		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
 	}
-	res, err := t.db.Pool.Exec(ctx, sql, args...)
+	task, err := scanTask(t.db.Conn(ctx).QueryRow(ctx, sql, args...))
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23502": // not null violation
-				return fmt.Errorf("%w: missing required field", repository.ErrInvalidInput)
-			case "23514": // check constraint
-				return fmt.Errorf("%w: invalid value in field with CHECK", repository.ErrInvalidInput)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrConcurrentUpdate
+		}
+		return nil, mapWriteError(err)
+	}
+	if err = t.recordEvent(ctx, models.TaskUpdated, before, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Delete moves the task to the trash. If version is not 0 and differs from the current one,
+// ErrVersionMismatch is returned.
+func (t *Tasks) Delete(ctx context.Context, id int, version int) (*models.Task, error) {
+	var task *models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		before, err := t.lockTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt != nil {
+			return repository.ErrNotFound
+		}
+		if version != 0 && version != before.Version {
+			return repository.ErrVersionMismatch
+		}
+		now := time.Now().UTC()
+		sql, args, err := t.db.Builder.Update(tasksTable).
+			Set("deleted_at", now).
+			Set("updated_at", now).
+			Set("version", squirrel.Expr("version + 1")).
+			Where("id = ?", id).
+			Suffix("RETURNING " + strings.Join(taskColumns, ", ")).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		task, err = scanTask(t.db.Conn(ctx).QueryRow(ctx, sql, args...))
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+		return t.recordEvent(ctx, models.TaskDeleted, before, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Restore takes the task out of the trash.
+func (t *Tasks) Restore(ctx context.Context, id int) (*models.Task, error) {
+	var task *models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		before, err := t.lockTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return repository.ErrNotFound
+		}
+		task, err = t.restore(ctx, before)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// DetachProject moves the tasks of the project to the inbox before the project is deleted, with trash
+// the tasks that are not in the trash yet are moved there as well. The changes are recorded in the history.
+func (t *Tasks) DetachProject(ctx context.Context, projectID int, trash bool) error {
+	return t.db.WithTx(ctx, func(ctx context.Context) error {
+		before, err := t.lockTasks(ctx, squirrel.Eq{"project_id": projectID})
+		if err != nil {
+			return err
+		}
+		if len(before) == 0 {
+			return nil
+		}
+		now := time.Now().UTC()
+		update := t.db.Builder.Update(tasksTable).
+			Set("project_id", nil).
+			Set("updated_at", now).
+			Set("version", squirrel.Expr("version + 1"))
+		if trash {
+			update = update.Set("deleted_at", squirrel.Expr("COALESCE(deleted_at, ?)", now))
+		}
+		sql, args, err := update.Where("project_id = ?", projectID).
+			Suffix("RETURNING " + strings.Join(taskColumns, ", ")).ToSql()
+		if err != nil {
+			return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		detached, err := t.queryTasks(ctx, sql, args, len(before))
+		if err != nil {
+			return err
+		}
+		for _, task := range detached {
+			action := models.TaskUpdated
+			if before[task.ID].DeletedAt == nil && task.DeletedAt != nil {
+				action = models.TaskDeleted
+			}
+			if err = t.recordEvent(ctx, action, before[task.ID], task); err != nil {
+				return err
 			}
 		}
+		return nil
+	})
+}
+
+// restore takes the locked task out of the trash.
+func (t *Tasks) restore(ctx context.Context, before *models.Task) (*models.Task, error) {
+	sql, args, err := t.db.Builder.Update(tasksTable).
+		Set("deleted_at", nil).
+		Set("updated_at", time.Now().UTC()).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("id = ?", before.ID).
+		Suffix("RETURNING " + strings.Join(taskColumns, ", ")).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	task, err := scanTask(t.db.Conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	if err = t.recordEvent(ctx, models.TaskRestored, before, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Purge permanently removes tasks moved to the trash before deletedBefore
+// and returns the number of removed tasks.
+func (t *Tasks) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	sql, args, err := t.db.Builder.Delete(tasksTable).
+		Where("deleted_at < ?", deletedBefore).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	res, err := t.db.Conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// setTags replaces tags of the task with the given names, creating missing tags.
+func (t *Tasks) setTags(ctx context.Context, taskID int, names []string) error {
+	sql, args, err := t.db.Builder.Delete(taskTagsTable).Where("task_id = ?", taskID).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if err = t.createTags(ctx, names); err != nil {
+		return err
+	}
+
+	sql, args, err = t.db.Builder.Insert(taskTagsTable).Columns("task_id", "tag_id").
+		Select(t.db.Builder.Select().Column("?::integer", taskID).Column("id").
+			From(tagsTable).Where(squirrel.Eq{"name": names})).ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+// createTags creates the tags with the given names that don't exist yet.
+func (t *Tasks) createTags(ctx context.Context, names []string) error {
+	createdAt := time.Now().UTC()
+	insertTags := t.db.Builder.Insert(tagsTable).Columns("name", "created_at")
+	for _, name := range names {
+		insertTags = insertTags.Values(name, createdAt)
+	}
+	sql, args, err := insertTags.Suffix("ON CONFLICT (name) DO NOTHING").ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+// projectExists returns ErrRelatedNotFound if there is no project with the id.
+func (t *Tasks) projectExists(ctx context.Context, id int) error {
+	sql, args, err := t.db.Builder.Select("1").From(projectsTable).Where("id = ?", id).Prefix("SELECT EXISTS (").Suffix(")").ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var exists bool
+	if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
 		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
 	}
-	if res.RowsAffected() == 0 {
-		return repository.ErrConcurrentUpdate
+	if !exists {
+		return fmt.Errorf("%w: project %d", repository.ErrRelatedNotFound, id)
 	}
 	return nil
 }
 
-func (t *Tasks) Delete(ctx context.Context, id int) (*models.Task, error) {
-	sql, args, err := t.db.Builder.Delete(tasksTable).Where("id = ?", id).
-		Suffix("RETURNING id, title, description, status, created_at, updated_at").ToSql()
+// ExistingProjects returns the ids of the given ones that belong to existing projects.
+func (t *Tasks) ExistingProjects(ctx context.Context, ids []int) ([]int, error) {
+	sql, args, err := t.db.Builder.Select("id").From(projectsTable).Where(squirrel.Eq{"id": ids}).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
 	}
-	var task models.Task
-	err = t.db.Pool.QueryRow(ctx, sql, args...).
-		Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt)
+	rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, repository.ErrNotFound
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	return existing, nil
+}
+
+// applyFilter adds WHERE clauses matching the filter to the query.
+func applyFilter(query squirrel.SelectBuilder, filter *models.ListFilter) squirrel.SelectBuilder {
+	if filter == nil {
+		return query.Where("deleted_at IS NULL")
+	}
+	if filter.Trashed {
+		query = query.Where("deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+	if filter.ProjectID != nil {
+		query = query.Where(squirrel.Eq{"project_id": *filter.ProjectID})
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where(squirrel.Eq{"status": filter.Statuses})
+	}
+	if len(filter.Priorities) > 0 {
+		priorities := make([]int16, 0, len(filter.Priorities))
+		for _, p := range filter.Priorities {
+			priorities = append(priorities, int16(p))
 		}
+		query = query.Where(squirrel.Eq{"priority": priorities})
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where(squirrel.GtOrEq{"created_at": *filter.CreatedAfter})
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where(squirrel.Lt{"created_at": *filter.CreatedBefore})
+	}
+	if filter.UpdatedAfter != nil {
+		query = query.Where(squirrel.GtOrEq{"updated_at": *filter.UpdatedAfter})
+	}
+	if filter.UpdatedBefore != nil {
+		query = query.Where(squirrel.Lt{"updated_at": *filter.UpdatedBefore})
+	}
+	if filter.DueAfter != nil {
+		query = query.Where(squirrel.GtOrEq{"due_at": *filter.DueAfter})
+	}
+	if filter.DueBefore != nil {
+		query = query.Where(squirrel.Lt{"due_at": *filter.DueBefore})
+	}
+	if filter.Overdue {
+		query = query.Where("due_at < now()").Where("status NOT IN " + terminalStatuses)
+	}
+	if len(filter.Tags) > 0 {
+		query = query.Where(`EXISTS (SELECT 1 FROM task_tags tt JOIN tags tg ON tg.id = tt.tag_id
+			WHERE tt.task_id = tasks.id AND tg.name = ANY(?))`, filter.Tags)
+	}
+	if filter.Title != "" {
+		query = query.Where(squirrel.ILike{"title": "%" + likeEscaper.Replace(filter.Title) + "%"})
+	}
+	return query
+}
+
+// likeEscaper escapes LIKE wildcards so that user input is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// mapWriteError converts constraint violations on insert or update of a task to repository errors.
+func mapWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23502": // not null violation
+			return fmt.Errorf("%w: missing required field", repository.ErrInvalidInput)
+		case "23514": // check constraint
+			return fmt.Errorf("%w: invalid value in field with CHECK", repository.ErrInvalidInput)
+		case "23503": // foreign key violation
+			if pgErr.ConstraintName == "fk_tasks_status" {
+				return fmt.Errorf("%w: unknown status", repository.ErrInvalidInput)
+			}
+			return fmt.Errorf("%w: %s", repository.ErrRelatedNotFound, pgErr.ConstraintName)
+		}
+	}
+	return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+}
+
+// queryTasks runs a query selecting taskColumns and scans all rows.
+func (t *Tasks) queryTasks(ctx context.Context, sql string, args []any, capacity int) ([]*models.Task, error) {
+	rows, err := t.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
 		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
 	}
+	defer rows.Close()
+
+	tasks := make([]*models.Task, 0, capacity)
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrRetrievingData, err)
+	}
+	return tasks, nil
+}
+
+// scanTask reads a row selected with taskColumns.
+func scanTask(row pgx.Row) (*models.Task, error) {
+	var task models.Task
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.StatusReason, &task.Priority, &task.Position, &task.ProjectID, &task.ParentID, &task.Recurrence, &task.SeriesID, &task.DueAt, &task.ReminderAt, &task.ReminderSentAt,
+		&task.CreatedAt, &task.UpdatedAt, &task.Version, &task.DeletedAt, &task.Tags, &task.BlockedBy, &task.Blocked, &task.CommentCount)
+	if err != nil {
+		return nil, err
+	}
 	return &task, nil
 }
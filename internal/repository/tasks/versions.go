@@ -0,0 +1,140 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+const versionsTable = "task_versions"
+
+// saveVersion keeps the snapshot of the task under its current version.
+func (t *Tasks) saveVersion(ctx context.Context, task *models.Task) error {
+	sql, args, err := t.db.Builder.Insert(versionsTable).
+		Columns("task_id", "version", "snapshot", "created_at").
+		Values(task.ID, task.Version, task, time.Now().UTC()).
+		Suffix("ON CONFLICT (task_id, version) DO UPDATE SET snapshot = EXCLUDED.snapshot").ToSql()
+	if err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return nil
+}
+
+// Snapshot returns the task as it was in the given version, ErrNotFound is returned if there is no such version.
+func (t *Tasks) Snapshot(ctx context.Context, id int, version int) (*models.Task, error) {
+	return t.snapshot(ctx, squirrel.Eq{"task_id": id, "version": version})
+}
+
+// lastSnapshot returns the latest saved version of the task.
+func (t *Tasks) lastSnapshot(ctx context.Context, id int) (*models.Task, error) {
+	return t.snapshot(ctx, squirrel.Eq{"task_id": id})
+}
+
+func (t *Tasks) snapshot(ctx context.Context, where squirrel.Eq) (*models.Task, error) {
+	sql, args, err := t.db.Builder.Select("snapshot").From(versionsTable).
+		Where(where).OrderBy("version DESC").Limit(1).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	var task models.Task
+	if err = t.db.Conn(ctx).QueryRow(ctx, sql, args...).Scan(&task); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+	}
+	return &task, nil
+}
+
+// Undelete takes the task out of the trash or, if it has already been purged, recreates it from
+// its last snapshot. A project, parent task or dependency that no longer exists is not restored.
+// ErrNotFound is returned if the task is not deleted or has no snapshots. If version is not 0 and
+// differs from the version of the deleted task, ErrVersionMismatch is returned.
+func (t *Tasks) Undelete(ctx context.Context, id int, version int) (*models.Task, error) {
+	var task *models.Task
+	err := t.db.WithTx(ctx, func(ctx context.Context) error {
+		before, err := t.lockTask(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			task, err = t.recreate(ctx, id, version)
+			return err
+		} else if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return repository.ErrNotFound
+		}
+		if version != 0 && version != before.Version {
+			return repository.ErrVersionMismatch
+		}
+		task, err = t.restore(ctx, before)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// recreate inserts the purged task with its id from the last snapshot.
+// If the task is being recreated concurrently, ErrNotFound is returned.
+func (t *Tasks) recreate(ctx context.Context, id int, version int) (*models.Task, error) {
+	snapshot, err := t.lastSnapshot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != snapshot.Version {
+		return nil, repository.ErrVersionMismatch
+	}
+	if err = t.lockColumn(ctx, snapshot.Status); err != nil {
+		return nil, err
+	}
+	sql, args, err := t.db.Builder.Insert(tasksTable).
+		Columns("id", "title", "description", "status", "status_reason", "priority", "position", "project_id", "parent_id", "recurrence", "series_id", "due_at", "reminder_at", "created_at", "updated_at", "version").
+		Values(id, snapshot.Title, snapshot.Description, snapshot.Status, snapshot.StatusReason, int16(snapshot.Priority),
+			endOfColumn(snapshot.Status, id),
+			squirrel.Expr("(SELECT id FROM projects WHERE id = ?)", snapshot.ProjectID),
+			squirrel.Expr("(SELECT id FROM tasks WHERE id = ?)", snapshot.ParentID),
+			snapshot.Recurrence, snapshot.SeriesID, snapshot.DueAt, snapshot.ReminderAt,
+			snapshot.CreatedAt, time.Now().UTC(), snapshot.Version+1).
+		Suffix("ON CONFLICT (id) DO NOTHING").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+	}
+	res, err := t.db.Conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return nil, mapWriteError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, repository.ErrNotFound
+	}
+	if err = t.setTags(ctx, id, snapshot.Tags); err != nil {
+		return nil, err
+	}
+	if len(snapshot.BlockedBy) > 0 {
+		sql, args, err = t.db.Builder.Insert(dependenciesTable).Columns("task_id", "blocked_by_id").
+			Select(t.db.Builder.Select().Column("?::integer", id).Column("id").
+				From(tasksTable).Where(squirrel.Eq{"id": snapshot.BlockedBy})).ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrBuildingSql, err)
+		}
+		if _, err = t.db.Conn(ctx).Exec(ctx, sql, args...); err != nil {
+			return nil, fmt.Errorf("%w: %s", repository.ErrExecutingSql, err)
+		}
+	}
+	task, err := t.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.recordEvent(ctx, models.TaskRestored, snapshot, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
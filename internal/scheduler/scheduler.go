@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is a background task run by the scheduler, errors are logged by the job itself.
+type Job func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler runs jobs periodically in the background. Every run gets its own request id,
+// so that its logs can be told apart the same way as the logs of HTTP requests.
+type Scheduler struct {
+	log    *slog.Logger
+	jobs   []job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(log *slog.Logger) *Scheduler {
+	return &Scheduler{log: log}
+}
+
+// Add registers the job, it must be called before Start.
+func (s *Scheduler) Add(name string, interval time.Duration, run Job) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start runs every job right away and then once per its interval until Stop is called.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+	s.log.Info("scheduler started", slog.Int("jobs", len(s.jobs)))
+}
+
+// Stop cancels the jobs and waits until the running ones return or ctx is done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	stopped := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		s.run(ctx, j)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, j job) {
+	const op = "scheduler.run"
+	requestID := uuid.New().String()
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+		slog.String("job", j.name),
+	)
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("job panicked", slog.Any("panic", r))
+		}
+	}()
+	if err := j.run(context.WithValue(ctx, request_id.RequestIDKey, requestID)); err != nil {
+		log.Error("job failed", sl.Err(err))
+	}
+}
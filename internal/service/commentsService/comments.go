@@ -0,0 +1,110 @@
+package commentsService
+
+import (
+	"context"
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+)
+
+type CommentsRepository interface {
+	Create(ctx context.Context, comment *models.Comment) (*models.Comment, error)
+	List(ctx context.Context, taskID int, page *models.Page) (*models.CommentPage, error)
+	Update(ctx context.Context, comment *models.Comment) (*models.Comment, error)
+	Delete(ctx context.Context, taskID int, id int) (*models.Comment, error)
+}
+
+type UseCase struct {
+	log  *slog.Logger
+	repo CommentsRepository
+}
+
+func NewUseCase(log *slog.Logger, repo CommentsRepository) *UseCase {
+	return &UseCase{
+		log:  log,
+		repo: repo,
+	}
+}
+
+func (u *UseCase) CreateComment(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	const op = "service.comments.CreateComment"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Create(ctx, comment)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found", sl.Err(err), slog.Int("task_id", comment.TaskID))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to create comment", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("comment created", slog.Int("id", res.ID), slog.Int("task_id", res.TaskID))
+	return res, nil
+}
+
+func (u *UseCase) ListComments(ctx context.Context, taskID int, page *models.Page) (*models.CommentPage, error) {
+	const op = "service.comments.ListComments"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.List(ctx, taskID, page)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found", sl.Err(err), slog.Int("task_id", taskID))
+		return nil, service.ErrNotFound
+	} else if errors.Is(err, repository.ErrInvalidInput) {
+		log.Error("invalid input", sl.Err(err))
+		return nil, service.ErrInvalidInput
+	} else if err != nil {
+		log.Error("failed to get list of comments", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("comments list received", slog.Int("count", len(res.Comments)), slog.Int("total", res.Total))
+	return res, nil
+}
+
+func (u *UseCase) UpdateComment(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	const op = "service.comments.UpdateComment"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Update(ctx, comment)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("comment not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to update comment", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("comment updated", slog.Int("id", res.ID))
+	return res, nil
+}
+
+func (u *UseCase) DeleteComment(ctx context.Context, taskID int, id int) (*models.Comment, error) {
+	const op = "service.comments.DeleteComment"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Delete(ctx, taskID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("comment not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to delete comment", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("comment deleted", slog.Int("id", id))
+	return res, nil
+}
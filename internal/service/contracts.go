@@ -3,16 +3,134 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/igorgrichanov/toDoList/internal/models"
 )
 
 var ErrInternal = errors.New("internal server error")
 var ErrNotFound = errors.New("not found")
 var ErrConflict = errors.New("already exists")
+var ErrInvalidInput = errors.New("invalid input")
+var ErrPreconditionFailed = errors.New("precondition failed")
+var ErrRelatedNotFound = errors.New("related entity not found")
+var ErrNotEmpty = errors.New("not empty")
+var ErrParentNotFound = errors.New("parent task not found")
+var ErrInvalidParent = errors.New("task can't be a subtask of itself or of its subtasks")
+var ErrOpenSubtasks = errors.New("task has subtasks that are not done")
+var ErrDependencyCycle = errors.New("dependency makes a cycle")
+var ErrBlocked = errors.New("task is blocked")
+var ErrInvalidTransition = errors.New("invalid status transition")
+var ErrInvalidPosition = errors.New("neighbours are not in the target column")
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+var ErrVersionNotFound = errors.New("task version not found")
+var ErrNotApplied = errors.New("not applied because another operation failed")
+
+// TransitionError is returned when the workflow doesn't allow the status change, it matches ErrInvalidTransition.
+type TransitionError struct {
+	From string
+	To   string
+	// ReasonRequired is true if the change is allowed only with a reason.
+	ReasonRequired bool
+}
+
+func (e *TransitionError) Error() string {
+	if e.ReasonRequired {
+		return fmt.Sprintf("changing status from '%s' to '%s' requires a reason", e.From, e.To)
+	}
+	return fmt.Sprintf("status can't be changed from '%s' to '%s'", e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
 
 type Tasks interface {
+	// CreateTask, UpdateTask, PatchTask and ListTasks return ErrRelatedNotFound if the project doesn't exist.
+	// ErrParentNotFound and ErrInvalidParent are returned if the parent task doesn't exist or would make a cycle,
+	// ErrOpenSubtasks if the task can't be done because of its subtasks, ErrBlocked if the task
+	// can't leave the first status or be finished because of its dependencies. *TransitionError is returned if the workflow doesn't allow
+	// the status change. ErrInvalidRecurrence is returned if the RRULE can't be parsed.
+	// When a recurring task reaches a terminal status, the next occurrence of its series is created.
 	CreateTask(ctx context.Context, task *models.Task) (*models.Task, error)
-	ListTasks(ctx context.Context) ([]*models.Task, error)
-	UpdateTask(ctx context.Context, task *models.Task) error
-	DeleteTask(ctx context.Context, id int) (*models.Task, error)
+	ListTasks(ctx context.Context, filter *models.ListFilter, page *models.Page) (*models.TaskPage, error)
+	// SearchTasks returns tasks matching the full-text query ordered by rank.
+	SearchTasks(ctx context.Context, query *models.SearchQuery) (*models.SearchPage, error)
+	GetTask(ctx context.Context, id int) (*models.Task, error)
+	// GetSubtasks returns direct subtasks of the task, GetSubtree returns the task with all of its descendants.
+	GetSubtasks(ctx context.Context, id int) ([]*models.Task, error)
+	GetSubtree(ctx context.Context, id int) ([]*models.Task, error)
+	// GetHistory returns the changes of the task from the oldest to the newest, including the ones made
+	// before the task was deleted.
+	GetHistory(ctx context.Context, id int) ([]*models.TaskEvent, error)
+	// UpdateTask and PatchTask return ErrPreconditionFailed if the version of the task
+	// differs from the expected one, 0 version skips the check.
+	UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error)
+	PatchTask(ctx context.Context, patch *models.TaskPatch) (*models.Task, error)
+	// TransitionTask changes the status of the task, reason is saved along with it.
+	TransitionTask(ctx context.Context, id int, status string, reason string, version int) (*models.Task, error)
+	// MoveTask puts the task between its neighbours in the status column, ErrInvalidPosition is returned
+	// if a neighbour is not in the column.
+	MoveTask(ctx context.Context, move *models.TaskMove) (*models.Task, error)
+	// AddDependency makes the task blocked by another one, ErrRelatedNotFound is returned if the other task
+	// doesn't exist and ErrDependencyCycle if it already depends on the task.
+	AddDependency(ctx context.Context, id int, blockedByID int) (*models.Task, error)
+	RemoveDependency(ctx context.Context, id int, blockedByID int) (*models.Task, error)
+	// GetSeries returns all occurrences of the recurring task, UpdateSeries changes the ones that are not done yet
+	// and StopSeries removes the recurrence from the series.
+	GetSeries(ctx context.Context, seriesID int) ([]*models.Task, error)
+	UpdateSeries(ctx context.Context, seriesID int, patch *models.SeriesPatch) ([]*models.Task, error)
+	StopSeries(ctx context.Context, seriesID int) ([]*models.Task, error)
+	// DeleteTask moves the task to the trash, RestoreTask takes it back.
+	DeleteTask(ctx context.Context, id int, version int) (*models.Task, error)
+	RestoreTask(ctx context.Context, id int) (*models.Task, error)
+	// RevertTask applies the task as it was in the given version as a new update following the rules of UpdateTask,
+	// ErrVersionNotFound is returned if the version hasn't been kept. Without the expected version ErrConflict
+	// is returned if the task is changed concurrently.
+	RevertTask(ctx context.Context, id int, to int, version int) (*models.Task, error)
+	// UndeleteTask takes the task out of the trash or recreates it from its last version if it has been purged.
+	UndeleteTask(ctx context.Context, id int, version int) (*models.Task, error)
+	// BulkTasks applies create, update, patch and delete operations in one transaction. In atomic mode
+	// a failed operation rolls back all of them and the others get ErrNotApplied, otherwise only the failed
+	// operations are skipped. Results hold the same errors as the single methods.
+	BulkTasks(ctx context.Context, ops []*models.BulkOperation, atomic bool) ([]*models.BulkResult, error)
+	// ImportTasks creates the tasks of the valid rows in one transaction and reports the rejected ones,
+	// nothing is created in dry run. ErrRelatedNotFound is returned if a project or a parent task
+	// has been deleted during the import.
+	ImportTasks(ctx context.Context, rows []*models.ImportRow, dryRun bool) (*models.ImportReport, error)
+	// PurgeTrash permanently removes tasks kept in the trash longer than the retention period.
+	PurgeTrash(ctx context.Context) (int64, error)
+}
+
+type Tags interface {
+	CreateTag(ctx context.Context, tag *models.Tag) (*models.Tag, error)
+	ListTags(ctx context.Context) ([]*models.Tag, error)
+	GetTag(ctx context.Context, id int) (*models.Tag, error)
+	UpdateTag(ctx context.Context, tag *models.Tag) (*models.Tag, error)
+	DeleteTag(ctx context.Context, id int) (*models.Tag, error)
+}
+
+type Projects interface {
+	CreateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	ListProjects(ctx context.Context) ([]*models.Project, error)
+	GetProject(ctx context.Context, id int) (*models.Project, error)
+	UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	// DeleteProject handles tasks of the project according to the mode, empty mode means the configured default.
+	// ErrNotEmpty is returned if the project has tasks and the mode is reject.
+	DeleteProject(ctx context.Context, id int, mode models.ProjectDeleteMode) (*models.Project, error)
+}
+
+// Comments return ErrNotFound if the task doesn't exist or is in the trash, or the comment doesn't belong to it.
+type Comments interface {
+	CreateComment(ctx context.Context, comment *models.Comment) (*models.Comment, error)
+	// ListComments returns ErrInvalidInput if the cursor is malformed.
+	ListComments(ctx context.Context, taskID int, page *models.Page) (*models.CommentPage, error)
+	UpdateComment(ctx context.Context, comment *models.Comment) (*models.Comment, error)
+	DeleteComment(ctx context.Context, taskID int, id int) (*models.Comment, error)
+}
+
+type Statuses interface {
+	// CreateStatus returns ErrConflict if the status already exists.
+	CreateStatus(ctx context.Context, status *models.Status) (*models.Status, error)
+	// ListStatuses returns statuses in the board order. The list is cached and must not be changed.
+	ListStatuses(ctx context.Context) ([]*models.Status, error)
 }
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/actor"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+)
+
+// WithEventSource passes the request id and the actor of the request to the repository,
+// which saves them in the history of the changed tasks. Changes without an actor, e.g. made by background jobs,
+// are saved as made by the system.
+func WithEventSource(ctx context.Context) context.Context {
+	requestID, _ := ctx.Value(request_id.RequestIDKey).(string)
+	who, _ := ctx.Value(actor.ActorKey).(string)
+	return models.WithEventSource(ctx, models.EventSource{RequestID: requestID, Actor: who})
+}
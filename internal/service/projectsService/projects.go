@@ -0,0 +1,134 @@
+package projectsService
+
+import (
+	"context"
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/config"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+)
+
+type ProjectsRepository interface {
+	Create(ctx context.Context, project *models.Project) (*models.Project, error)
+	List(ctx context.Context) ([]*models.Project, error)
+	GetByID(ctx context.Context, id int) (*models.Project, error)
+	Update(ctx context.Context, project *models.Project) (*models.Project, error)
+	Delete(ctx context.Context, id int, mode models.ProjectDeleteMode) (*models.Project, error)
+}
+
+type UseCase struct {
+	log  *slog.Logger
+	repo ProjectsRepository
+	conf *config.Projects
+}
+
+func NewUseCase(log *slog.Logger, repo ProjectsRepository, conf *config.Projects) *UseCase {
+	return &UseCase{
+		log:  log,
+		repo: repo,
+		conf: conf,
+	}
+}
+
+func (u *UseCase) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	const op = "service.projects.CreateProject"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Create(ctx, project)
+	if err != nil {
+		log.Error("failed to create project", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("project created", slog.Any("id", res.ID))
+	return res, nil
+}
+
+func (u *UseCase) ListProjects(ctx context.Context) ([]*models.Project, error) {
+	const op = "service.projects.ListProjects"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.List(ctx)
+	if err != nil {
+		log.Error("failed to get list of projects", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("projects list received", slog.Int("count", len(res)))
+	return res, nil
+}
+
+func (u *UseCase) GetProject(ctx context.Context, id int) (*models.Project, error) {
+	const op = "service.projects.GetProject"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to get project", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("project received", slog.Any("id", id))
+	return res, nil
+}
+
+func (u *UseCase) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	const op = "service.projects.UpdateProject"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Update(ctx, project)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to update project", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("project updated", slog.Any("id", project.ID))
+	return res, nil
+}
+
+func (u *UseCase) DeleteProject(ctx context.Context, id int, mode models.ProjectDeleteMode) (*models.Project, error) {
+	const op = "service.projects.DeleteProject"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	if mode == "" {
+		mode = models.ProjectDeleteMode(u.conf.DeleteMode)
+	}
+	res, err := u.repo.Delete(ctx, id, mode)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if errors.Is(err, repository.ErrNotEmpty) {
+		log.Error("project has tasks", sl.Err(err))
+		return nil, service.ErrNotEmpty
+	} else if errors.Is(err, repository.ErrInvalidInput) {
+		log.Error("invalid delete mode", sl.Err(err), slog.String("mode", string(mode)))
+		return nil, service.ErrInvalidInput
+	} else if err != nil {
+		log.Error("failed to delete project", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("project deleted", slog.Any("id", id), slog.String("mode", string(mode)))
+	return res, nil
+}
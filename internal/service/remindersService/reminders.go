@@ -0,0 +1,92 @@
+package remindersService
+
+import (
+	"context"
+	"github.com/igorgrichanov/toDoList/internal/config"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"time"
+)
+
+type RemindersRepository interface {
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.Reminder, error)
+	MarkSent(ctx context.Context, reminders []*models.Reminder, sentAt time.Time) error
+}
+
+// Notifier delivers reminders, e.g. to the log, a webhook or by email.
+type Notifier interface {
+	Notify(ctx context.Context, reminder *models.Reminder) error
+}
+
+type UseCase struct {
+	log      *slog.Logger
+	repo     RemindersRepository
+	notifier Notifier
+	conf     *config.Reminders
+}
+
+func NewUseCase(log *slog.Logger, repo RemindersRepository, notifier Notifier, conf *config.Reminders) *UseCase {
+	return &UseCase{
+		log:      log,
+		repo:     repo,
+		notifier: notifier,
+		conf:     conf,
+	}
+}
+
+// SendDue sends all reminders that are due by now in batches. A reminder is sent at least once:
+// if the notifier fails, it is sent again after its lease expires. The database isn't kept busy while
+// the reminders are being sent, and sending stops when the lease of the batch expires.
+func (u *UseCase) SendDue(ctx context.Context) error {
+	const op = "service.reminders.SendDue"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	total := 0
+	for ctx.Err() == nil {
+		// every batch is claimed at its own time, otherwise the lease of a batch claimed after
+		// slow sends would have expired already and the reminders would be claimed twice
+		claimedAt := time.Now().UTC()
+		reminders, err := u.repo.Claim(ctx, claimedAt, u.conf.Lease, u.conf.BatchSize)
+		if err != nil {
+			log.Error("failed to claim reminders", sl.Err(err))
+			return service.ErrInternal
+		}
+		total += len(reminders)
+		sent := u.send(ctx, log, reminders, claimedAt.Add(u.conf.Lease))
+		if err = u.repo.MarkSent(ctx, sent, time.Now().UTC()); err != nil {
+			log.Error("failed to mark reminders as sent", sl.Err(err))
+			return service.ErrInternal
+		}
+		// a full batch means there may be more due reminders, failed ones
+		// are leased, so they aren't claimed again by the next batch
+		if len(reminders) < u.conf.BatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Info("due reminders processed", slog.Int("count", total))
+	}
+	return nil
+}
+
+// send notifies about the reminders until the lease expires and returns the sent ones.
+func (u *UseCase) send(ctx context.Context, log *slog.Logger, reminders []*models.Reminder, leasedUntil time.Time) []*models.Reminder {
+	ctx, cancel := context.WithDeadline(ctx, leasedUntil)
+	defer cancel()
+	sent := make([]*models.Reminder, 0, len(reminders))
+	for _, reminder := range reminders {
+		if err := u.notifier.Notify(ctx, reminder); err != nil {
+			log.Error("failed to send reminder", sl.Err(err), slog.Int("task_id", reminder.TaskID))
+			continue
+		}
+		log.Info("reminder sent", slog.Int("task_id", reminder.TaskID))
+		sent = append(sent, reminder)
+	}
+	return sent
+}
@@ -0,0 +1,93 @@
+package statusesService
+
+import (
+	"context"
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type StatusesRepository interface {
+	Create(ctx context.Context, status *models.Status) (*models.Status, error)
+	List(ctx context.Context) ([]*models.Status, error)
+}
+
+// cacheTTL bounds how long other instances of the service may miss a created status.
+const cacheTTL = time.Minute
+
+type UseCase struct {
+	log  *slog.Logger
+	repo StatusesRepository
+
+	// statuses are checked for every status field of the requests, so the list is cached
+	// until a status is created or the cache expires
+	mu       sync.RWMutex
+	cached   []*models.Status
+	cachedAt time.Time
+	// generation is increased when the cache is dropped, so that a list read before that isn't cached
+	generation int
+}
+
+func NewUseCase(log *slog.Logger, repo StatusesRepository) *UseCase {
+	return &UseCase{
+		log:  log,
+		repo: repo,
+	}
+}
+
+func (u *UseCase) CreateStatus(ctx context.Context, status *models.Status) (*models.Status, error) {
+	const op = "service.statuses.CreateStatus"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Create(ctx, status)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		log.Error("status already exists", sl.Err(err), slog.String("name", status.Name))
+		return nil, service.ErrConflict
+	} else if err != nil {
+		log.Error("failed to create status", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	u.mu.Lock()
+	u.cached = nil
+	u.generation++
+	u.mu.Unlock()
+	log.Info("status created", slog.String("name", res.Name))
+	return res, nil
+}
+
+func (u *UseCase) ListStatuses(ctx context.Context) ([]*models.Status, error) {
+	const op = "service.statuses.ListStatuses"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	u.mu.RLock()
+	res, cachedAt, generation := u.cached, u.cachedAt, u.generation
+	u.mu.RUnlock()
+	if res != nil && time.Since(cachedAt) < cacheTTL {
+		return res, nil
+	}
+
+	res, err := u.repo.List(ctx)
+	if err != nil {
+		log.Error("failed to get list of statuses", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	u.mu.Lock()
+	if u.generation == generation {
+		u.cached, u.cachedAt = res, time.Now()
+	}
+	u.mu.Unlock()
+	log.Debug("statuses list received", slog.Int("count", len(res)))
+	return res, nil
+}
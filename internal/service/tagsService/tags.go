@@ -0,0 +1,129 @@
+package tagsService
+
+import (
+	"context"
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+)
+
+type TagsRepository interface {
+	Create(ctx context.Context, tag *models.Tag) (*models.Tag, error)
+	List(ctx context.Context) ([]*models.Tag, error)
+	GetByID(ctx context.Context, id int) (*models.Tag, error)
+	Update(ctx context.Context, tag *models.Tag) (*models.Tag, error)
+	Delete(ctx context.Context, id int) (*models.Tag, error)
+}
+
+type UseCase struct {
+	log  *slog.Logger
+	repo TagsRepository
+}
+
+func NewUseCase(log *slog.Logger, repo TagsRepository) *UseCase {
+	return &UseCase{
+		log:  log,
+		repo: repo,
+	}
+}
+
+func (u *UseCase) CreateTag(ctx context.Context, tag *models.Tag) (*models.Tag, error) {
+	const op = "service.tags.CreateTag"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	tag.Name = models.NormalizeTagName(tag.Name)
+	res, err := u.repo.Create(ctx, tag)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		log.Error("tag already exists", sl.Err(err), slog.String("name", tag.Name))
+		return nil, service.ErrConflict
+	} else if err != nil {
+		log.Error("failed to create tag", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("tag created", slog.Any("id", res.ID))
+	return res, nil
+}
+
+func (u *UseCase) ListTags(ctx context.Context) ([]*models.Tag, error) {
+	const op = "service.tags.ListTags"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.List(ctx)
+	if err != nil {
+		log.Error("failed to get list of tags", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("tags list received", slog.Int("count", len(res)))
+	return res, nil
+}
+
+func (u *UseCase) GetTag(ctx context.Context, id int) (*models.Tag, error) {
+	const op = "service.tags.GetTag"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("tag not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to get tag", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("tag received", slog.Any("id", id))
+	return res, nil
+}
+
+func (u *UseCase) UpdateTag(ctx context.Context, tag *models.Tag) (*models.Tag, error) {
+	const op = "service.tags.UpdateTag"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	tag.Name = models.NormalizeTagName(tag.Name)
+	res, err := u.repo.Update(ctx, tag)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("tag not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if errors.Is(err, repository.ErrAlreadyExists) {
+		log.Error("tag already exists", sl.Err(err), slog.String("name", tag.Name))
+		return nil, service.ErrConflict
+	} else if err != nil {
+		log.Error("failed to update tag", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("tag updated", slog.Any("id", tag.ID))
+	return res, nil
+}
+
+func (u *UseCase) DeleteTag(ctx context.Context, id int) (*models.Tag, error) {
+	const op = "service.tags.DeleteTag"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Delete(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("tag not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to delete tag", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("tag deleted", slog.Any("id", id))
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package tasksService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+)
+
+// errRollback makes the transaction of an atomic bulk roll back after an operation has failed.
+var errRollback = errors.New("bulk operation failed")
+
+// BulkTasks applies the operations in one transaction in the order they are listed. In atomic mode the first
+// failed operation rolls back all of them, otherwise each operation runs in a savepoint and only the failed
+// ones are rolled back. Errors of the operations are the same as of the single methods.
+func (u *UseCase) BulkTasks(ctx context.Context, ops []*models.BulkOperation, atomic bool) ([]*models.BulkResult, error) {
+	const op = "service.tasks.BulkTasks"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	results := make([]*models.BulkResult, len(ops))
+	err := u.repo.InTx(ctx, func(ctx context.Context) error {
+		for i, operation := range ops {
+			var task *models.Task
+			apply := func(ctx context.Context) error {
+				var err error
+				task, err = u.applyBulk(ctx, operation)
+				return err
+			}
+			var err error
+			if atomic {
+				err = apply(ctx)
+			} else {
+				err = u.repo.InTx(ctx, apply)
+			}
+			results[i] = &models.BulkResult{Task: task, Err: err}
+			if err != nil && atomic {
+				log.Error("bulk operation failed, rolling back", slog.Int("index", i), sl.Err(err))
+				return errRollback
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errRollback) {
+		for i, res := range results {
+			if res == nil || res.Err == nil {
+				results[i] = &models.BulkResult{Err: service.ErrNotApplied}
+			}
+		}
+		return results, nil
+	} else if err != nil {
+		log.Error("failed to commit bulk operations", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	log.Info("bulk operations applied", slog.Int("count", len(ops)), slog.Int("failed", failed))
+	return results, nil
+}
+
+func (u *UseCase) applyBulk(ctx context.Context, operation *models.BulkOperation) (*models.Task, error) {
+	switch operation.Action {
+	case models.BulkCreate:
+		return u.CreateTask(ctx, operation.Task)
+	case models.BulkUpdate:
+		return u.UpdateTask(ctx, operation.Task)
+	case models.BulkPatch:
+		return u.PatchTask(ctx, operation.Patch)
+	case models.BulkDelete:
+		return u.DeleteTask(ctx, operation.ID, operation.Version)
+	default:
+		return nil, fmt.Errorf("%w: unknown bulk action '%s'", service.ErrInvalidInput, operation.Action)
+	}
+}
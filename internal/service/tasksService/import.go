@@ -0,0 +1,113 @@
+package tasksService
+
+import (
+	"context"
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"log/slog"
+)
+
+// ImportTasks checks the rows the same way as CreateTask and inserts the valid ones in one transaction.
+// Rows with an invalid recurrence rule or referring to a missing project or parent task are rejected,
+// nothing is written in dry run.
+func (u *UseCase) ImportTasks(ctx context.Context, rows []*models.ImportRow, dryRun bool) (*models.ImportReport, error) {
+	const op = "service.tasks.ImportTasks"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	report := &models.ImportReport{
+		DryRun:   dryRun,
+		Accepted: make([]*models.ImportedRow, 0, len(rows)),
+		Rejected: make([]*models.RejectedRow, 0),
+	}
+
+	var projectIDs []int
+	for _, row := range rows {
+		if row.Task.ProjectID != nil {
+			projectIDs = append(projectIDs, *row.Task.ProjectID)
+		}
+	}
+	projects := make(map[int]bool)
+	if len(projectIDs) > 0 {
+		existing, err := u.repo.ExistingProjects(ctx, projectIDs)
+		if err != nil {
+			log.Error("failed to check projects", sl.Err(err))
+			return nil, service.ErrInternal
+		}
+		for _, id := range existing {
+			projects[id] = true
+		}
+	}
+
+	// parents holds the result of the check by the parent id, many rows usually share the same parent
+	parents := make(map[int]error)
+	valid := make([]*models.ImportRow, 0, len(rows))
+	for _, row := range rows {
+		task := row.Task
+		if task.ProjectID != nil && !projects[*task.ProjectID] {
+			report.Rejected = append(report.Rejected, &models.RejectedRow{Line: row.Line, Message: "project not found"})
+			continue
+		}
+		if task.ParentID != nil {
+			err, ok := parents[*task.ParentID]
+			if !ok {
+				err = u.checkParent(ctx, log, *task.ParentID)
+				parents[*task.ParentID] = err
+			}
+			if errors.Is(err, service.ErrParentNotFound) {
+				report.Rejected = append(report.Rejected, &models.RejectedRow{Line: row.Line, Message: "parent task not found"})
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		if task.Recurrence != "" {
+			rule, err := normalizeRecurrence(task.Recurrence, recurrenceStart(task.DueAt))
+			if err != nil {
+				report.Rejected = append(report.Rejected, &models.RejectedRow{Line: row.Line, Message: "invalid recurrence rule"})
+				continue
+			}
+			task.Recurrence = rule
+		}
+		if task.Priority == 0 {
+			task.Priority = models.PriorityMedium
+		}
+		task.Tags = uniqueTags(task.Tags)
+		valid = append(valid, row)
+	}
+
+	if dryRun || len(valid) == 0 {
+		for _, row := range valid {
+			report.Accepted = append(report.Accepted, &models.ImportedRow{Line: row.Line})
+		}
+		log.Info("import checked", slog.Int("accepted", len(report.Accepted)), slog.Int("rejected", len(report.Rejected)))
+		return report, nil
+	}
+
+	tasks := make([]*models.Task, len(valid))
+	for i, row := range valid {
+		tasks[i] = row.Task
+	}
+	imported, err := u.repo.Import(ctx, tasks)
+	if errors.Is(err, repository.ErrRelatedNotFound) {
+		// the project or the parent task has been deleted after the check
+		log.Error("project or parent task not found", sl.Err(err))
+		return nil, service.ErrRelatedNotFound
+	} else if err != nil {
+		log.Error("failed to import tasks", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	for i, row := range valid {
+		report.Accepted = append(report.Accepted, &models.ImportedRow{Line: row.Line, ID: imported[i].ID})
+	}
+
+	log.Info("tasks imported", slog.Int("accepted", len(report.Accepted)), slog.Int("rejected", len(report.Rejected)))
+	return report, nil
+}
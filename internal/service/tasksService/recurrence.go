@@ -0,0 +1,192 @@
+package tasksService
+
+import (
+	"context"
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
+	"github.com/teambition/rrule-go"
+	"log/slog"
+	"time"
+)
+
+// normalizeRecurrence validates the RRULE and adds DTSTART to it if the rule has none,
+// so that COUNT and UNTIL are counted from the first occurrence of the series.
+func normalizeRecurrence(rule string, start time.Time) (string, error) {
+	opt, err := rrule.StrToROption(rule)
+	if err != nil {
+		return "", err
+	}
+	if opt.Dtstart.IsZero() {
+		opt.Dtstart = start.UTC().Truncate(time.Second)
+	}
+	if _, err = rrule.NewRRule(*opt); err != nil {
+		return "", err
+	}
+	return opt.String(), nil
+}
+
+// nextOccurrence returns the first occurrence of the rule after the given time, nil if the series is over.
+func nextOccurrence(rule string, after time.Time) (*time.Time, error) {
+	r, err := rrule.StrToRRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	next := r.After(after, false)
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// recurrenceStart is the DTSTART of a rule given without one: the due date of the task or the current time.
+func recurrenceStart(dueAt *time.Time) time.Time {
+	if dueAt != nil {
+		return *dueAt
+	}
+	return time.Now()
+}
+
+// spawnNext creates the next occurrence of the recurring task that has just reached a terminal status,
+// it must be called in the transaction of the status change, so that the occurrence is created along with it.
+// Nothing is created if the series already has an open occurrence, e.g. when a reopened task is done again.
+// Failures are logged and returned as service.ErrInternal.
+func (u *UseCase) spawnNext(ctx context.Context, log *slog.Logger, task *models.Task) error {
+	if task.Recurrence == "" || task.SeriesID == nil {
+		return nil
+	}
+	status, err := u.statuses.GetByName(ctx, task.Status)
+	if err != nil {
+		log.Error("failed to get status", sl.Err(err))
+		return service.ErrInternal
+	}
+	if !status.Terminal {
+		return nil
+	}
+	open, err := u.repo.HasOpenOccurrence(ctx, *task.SeriesID)
+	if err != nil {
+		log.Error("failed to check open occurrences", sl.Err(err))
+		return service.ErrInternal
+	}
+	if open {
+		return nil
+	}
+	// occurrences missed while the task was overdue are skipped
+	after := time.Now().UTC()
+	if task.DueAt != nil && task.DueAt.After(after) {
+		after = *task.DueAt
+	}
+	dueAt, err := nextOccurrence(task.Recurrence, after)
+	if err != nil {
+		log.Error("failed to compute next occurrence", sl.Err(err), slog.String("recurrence", task.Recurrence))
+		return service.ErrInternal
+	}
+	if dueAt == nil {
+		log.Info("series is over", slog.Int("series_id", *task.SeriesID))
+		return nil
+	}
+	// the reminder keeps the same offset from the due date
+	var reminderAt *time.Time
+	if task.ReminderAt != nil && task.DueAt != nil {
+		at := dueAt.Add(task.ReminderAt.Sub(*task.DueAt))
+		reminderAt = &at
+	}
+	next, err := u.repo.Create(ctx, &models.Task{
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		ProjectID:   task.ProjectID,
+		ParentID:    task.ParentID,
+		Recurrence:  task.Recurrence,
+		SeriesID:    task.SeriesID,
+		DueAt:       dueAt,
+		ReminderAt:  reminderAt,
+		Tags:        task.Tags,
+	})
+	if err != nil {
+		log.Error("failed to create next occurrence", sl.Err(err), slog.Int("series_id", *task.SeriesID))
+		return service.ErrInternal
+	}
+	log.Info("next occurrence created", slog.Int("id", next.ID), slog.Int("series_id", *task.SeriesID), slog.Time("due_at", *dueAt))
+	return nil
+}
+
+func (u *UseCase) GetSeries(ctx context.Context, seriesID int) ([]*models.Task, error) {
+	const op = "service.tasks.GetSeries"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Series(ctx, seriesID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("series not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to get series", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("series received", slog.Int("series_id", seriesID), slog.Int("count", len(res)))
+	return res, nil
+}
+
+// UpdateSeries changes the occurrences that are not done yet. A new rule without DTSTART
+// starts from the due date of the last occurrence.
+func (u *UseCase) UpdateSeries(ctx context.Context, seriesID int, patch *models.SeriesPatch) ([]*models.Task, error) {
+	const op = "service.tasks.UpdateSeries"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	if patch.Recurrence != nil && *patch.Recurrence != "" {
+		series, err := u.repo.Series(ctx, seriesID)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Error("series not found", sl.Err(err))
+			return nil, service.ErrNotFound
+		} else if err != nil {
+			log.Error("failed to get series", sl.Err(err))
+			return nil, service.ErrInternal
+		}
+		rule, err := normalizeRecurrence(*patch.Recurrence, recurrenceStart(series[len(series)-1].DueAt))
+		if err != nil {
+			log.Error("invalid recurrence rule", sl.Err(err), slog.String("recurrence", *patch.Recurrence))
+			return nil, service.ErrInvalidRecurrence
+		}
+		patch.Recurrence = &rule
+	}
+	res, err := u.repo.UpdateSeries(ctx, seriesID, patch)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("series not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to update series", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("series updated", slog.Int("series_id", seriesID), slog.Int("count", len(res)))
+	return res, nil
+}
+
+func (u *UseCase) StopSeries(ctx context.Context, seriesID int) ([]*models.Task, error) {
+	const op = "service.tasks.StopSeries"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	res, err := u.repo.StopSeries(ctx, seriesID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("series not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to stop series", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("series stopped", slog.Int("series_id", seriesID))
+	return res, nil
+}
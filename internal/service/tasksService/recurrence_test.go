@@ -0,0 +1,89 @@
+package tasksService
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeRecurrence(t *testing.T) {
+	start := time.Date(2025, 5, 3, 10, 0, 0, 500, time.FixedZone("UTC+1", 3600))
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "start is added in UTC without fractions of a second",
+			rule: "FREQ=WEEKLY;BYDAY=SA",
+			want: "DTSTART:20250503T090000Z\nRRULE:FREQ=WEEKLY;BYDAY=SA",
+		},
+		{
+			name: "start of the rule is kept",
+			rule: "DTSTART:20250101T090000Z\nRRULE:FREQ=DAILY;COUNT=2",
+			want: "DTSTART:20250101T090000Z\nRRULE:FREQ=DAILY;COUNT=2",
+		},
+		{
+			name:    "unknown frequency",
+			rule:    "FREQ=SOMETIMES",
+			wantErr: true,
+		},
+		{
+			name:    "empty rule",
+			rule:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeRecurrence(tt.rule, start)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeRecurrence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeRecurrence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	const twoDays = "DTSTART:20250101T090000Z\nRRULE:FREQ=DAILY;COUNT=2"
+	second := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		rule    string
+		after   time.Time
+		want    *time.Time
+		wantErr bool
+	}{
+		{
+			name:  "occurrence at the given time is skipped",
+			rule:  twoDays,
+			after: time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+			want:  &second,
+		},
+		{
+			name:  "COUNT is exhausted",
+			rule:  twoDays,
+			after: second,
+		},
+		{
+			name:    "invalid rule",
+			rule:    "FREQ=SOMETIMES",
+			after:   second,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextOccurrence(tt.rule, tt.after)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("nextOccurrence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && !got.Equal(*tt.want) {
+				t.Errorf("nextOccurrence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,30 +3,66 @@ package tasksService
 import (
 	"context"
 	"errors"
+	"github.com/igorgrichanov/toDoList/internal/config"
 	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
 	"github.com/igorgrichanov/toDoList/internal/models"
 	"github.com/igorgrichanov/toDoList/internal/repository"
 	"github.com/igorgrichanov/toDoList/internal/service"
 	"github.com/igorgrichanov/toDoList/pkg/logger/sl"
 	"log/slog"
+	"slices"
+	"time"
 )
 
 type TasksRepository interface {
 	Create(ctx context.Context, task *models.Task) (*models.Task, error)
-	List(ctx context.Context) ([]*models.Task, error)
-	Update(ctx context.Context, task *models.Task) error
-	Delete(ctx context.Context, id int) (*models.Task, error)
+	List(ctx context.Context, filter *models.ListFilter, page *models.Page) (*models.TaskPage, error)
+	GetByID(ctx context.Context, id int) (*models.Task, error)
+	Update(ctx context.Context, patch *models.TaskPatch) (*models.Task, error)
+	Delete(ctx context.Context, id int, version int) (*models.Task, error)
+	Restore(ctx context.Context, id int) (*models.Task, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	Children(ctx context.Context, id int) ([]*models.Task, error)
+	Subtree(ctx context.Context, id int) ([]*models.Task, error)
+	Ancestors(ctx context.Context, id int) ([]int, error)
+	CountOpenDescendants(ctx context.Context, id int) (int, error)
+	AddDependency(ctx context.Context, taskID int, blockedByID int) (*models.Task, error)
+	RemoveDependency(ctx context.Context, taskID int, blockedByID int) (*models.Task, error)
+	Move(ctx context.Context, move *models.TaskMove) (*models.Task, error)
+	Series(ctx context.Context, seriesID int) ([]*models.Task, error)
+	HasOpenOccurrence(ctx context.Context, seriesID int) (bool, error)
+	UpdateSeries(ctx context.Context, seriesID int, patch *models.SeriesPatch) ([]*models.Task, error)
+	StopSeries(ctx context.Context, seriesID int) ([]*models.Task, error)
+	History(ctx context.Context, id int) ([]*models.TaskEvent, error)
+	Snapshot(ctx context.Context, id int, version int) (*models.Task, error)
+	Undelete(ctx context.Context, id int, version int) (*models.Task, error)
+	Search(ctx context.Context, query *models.SearchQuery) (*models.SearchPage, error)
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Import(ctx context.Context, tasks []*models.Task) ([]*models.Task, error)
+	ExistingProjects(ctx context.Context, ids []int) ([]int, error)
+}
+
+type StatusesRepository interface {
+	GetByName(ctx context.Context, name string) (*models.Status, error)
+	// List returns statuses in the board order.
+	List(ctx context.Context) ([]*models.Status, error)
 }
 
 type UseCase struct {
-	log  *slog.Logger
-	repo TasksRepository
+	log      *slog.Logger
+	repo     TasksRepository
+	statuses StatusesRepository
+	conf     *config.Tasks
+	workflow workflow
 }
 
-func NewUseCase(log *slog.Logger, repo TasksRepository) *UseCase {
+func NewUseCase(log *slog.Logger, repo TasksRepository, statuses StatusesRepository, conf *config.Tasks) *UseCase {
 	return &UseCase{
-		log:  log,
-		repo: repo,
+		log:      log,
+		repo:     repo,
+		statuses: statuses,
+		conf:     conf,
+		workflow: newWorkflow(conf.Workflow),
 	}
 }
 
@@ -37,8 +73,29 @@ func (u *UseCase) CreateTask(ctx context.Context, task *models.Task) (*models.Ta
 		slog.String("op", op),
 		slog.String("request_id", requestID),
 	)
+	ctx = service.WithEventSource(ctx)
+	if task.Priority == 0 {
+		task.Priority = models.PriorityMedium
+	}
+	task.Tags = uniqueTags(task.Tags)
+	if task.Recurrence != "" {
+		rule, err := normalizeRecurrence(task.Recurrence, recurrenceStart(task.DueAt))
+		if err != nil {
+			log.Error("invalid recurrence rule", sl.Err(err), slog.String("recurrence", task.Recurrence))
+			return nil, service.ErrInvalidRecurrence
+		}
+		task.Recurrence = rule
+	}
+	if task.ParentID != nil {
+		if err := u.checkParent(ctx, log, *task.ParentID); err != nil {
+			return nil, err
+		}
+	}
 	res, err := u.repo.Create(ctx, task)
-	if errors.Is(err, repository.ErrInvalidInput) {
+	if errors.Is(err, repository.ErrRelatedNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return nil, service.ErrRelatedNotFound
+	} else if errors.Is(err, repository.ErrInvalidInput) {
 		log.Error("invalid input", sl.Err(err))
 		return nil, service.ErrInternal
 	} else if err != nil {
@@ -50,58 +107,498 @@ func (u *UseCase) CreateTask(ctx context.Context, task *models.Task) (*models.Ta
 	return res, nil
 }
 
-func (u *UseCase) ListTasks(ctx context.Context) ([]*models.Task, error) {
+func (u *UseCase) ListTasks(ctx context.Context, filter *models.ListFilter, page *models.Page) (*models.TaskPage, error) {
 	const op = "service.tasks.ListTasks"
 	requestID := ctx.Value(request_id.RequestIDKey).(string)
 	log := u.log.With(
 		slog.String("op", op),
 		slog.String("request_id", requestID),
 	)
-	res, err := u.repo.List(ctx)
-	if err != nil {
+	res, err := u.repo.List(ctx, filter, page)
+	if errors.Is(err, repository.ErrRelatedNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return nil, service.ErrRelatedNotFound
+	} else if errors.Is(err, repository.ErrInvalidInput) {
+		log.Error("invalid input", sl.Err(err))
+		return nil, service.ErrInvalidInput
+	} else if err != nil {
 		log.Error("failed to get list of tasks", sl.Err(err))
 		return nil, service.ErrInternal
 	}
-	log.Info("tasks list received")
+	log.Info("tasks list received", slog.Int("count", len(res.Tasks)), slog.Int("total", res.Total))
+	return res, nil
+}
+
+// SearchTasks finds tasks by words of their titles and descriptions in the configured language.
+func (u *UseCase) SearchTasks(ctx context.Context, query *models.SearchQuery) (*models.SearchPage, error) {
+	const op = "service.tasks.SearchTasks"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	query.Language = u.conf.SearchLanguage
+	res, err := u.repo.Search(ctx, query)
+	if err != nil {
+		log.Error("failed to search tasks", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("tasks found", slog.Int("count", len(res.Results)), slog.Int("total", res.Total))
 	return res, nil
 }
 
-func (u *UseCase) UpdateTask(ctx context.Context, task *models.Task) error {
+func (u *UseCase) GetTask(ctx context.Context, id int) (*models.Task, error) {
+	const op = "service.tasks.GetTask"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to get task", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("task received", slog.Any("id", id))
+	return res, nil
+}
+
+func (u *UseCase) GetSubtasks(ctx context.Context, id int) ([]*models.Task, error) {
+	const op = "service.tasks.GetSubtasks"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Children(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to get subtasks", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("subtasks received", slog.Any("id", id), slog.Int("count", len(res)))
+	return res, nil
+}
+
+func (u *UseCase) GetSubtree(ctx context.Context, id int) ([]*models.Task, error) {
+	const op = "service.tasks.GetSubtree"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.Subtree(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to get subtree", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("subtree received", slog.Any("id", id), slog.Int("count", len(res)))
+	return res, nil
+}
+
+func (u *UseCase) GetHistory(ctx context.Context, id int) ([]*models.TaskEvent, error) {
+	const op = "service.tasks.GetHistory"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	res, err := u.repo.History(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task history not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to get task history", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("task history received", slog.Int("id", id), slog.Int("count", len(res)))
+	return res, nil
+}
+
+func (u *UseCase) AddDependency(ctx context.Context, id int, blockedByID int) (*models.Task, error) {
+	const op = "service.tasks.AddDependency"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	if id == blockedByID {
+		log.Error("task can't depend on itself", slog.Int("id", id))
+		return nil, service.ErrDependencyCycle
+	}
+	_, err := u.repo.GetByID(ctx, blockedByID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("blocking task not found", sl.Err(err), slog.Int("blocked_by", blockedByID))
+		return nil, service.ErrRelatedNotFound
+	} else if err != nil {
+		log.Error("failed to get blocking task", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	res, err := u.repo.AddDependency(ctx, id, blockedByID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if errors.Is(err, repository.ErrCycle) {
+		log.Error("dependency makes a cycle", sl.Err(err))
+		return nil, service.ErrDependencyCycle
+	} else if err != nil {
+		log.Error("failed to add dependency", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("dependency added", slog.Int("id", id), slog.Int("blocked_by", blockedByID))
+	return res, nil
+}
+
+func (u *UseCase) RemoveDependency(ctx context.Context, id int, blockedByID int) (*models.Task, error) {
+	const op = "service.tasks.RemoveDependency"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	res, err := u.repo.RemoveDependency(ctx, id, blockedByID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("dependency not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to remove dependency", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("dependency removed", slog.Int("id", id), slog.Int("blocked_by", blockedByID))
+	return res, nil
+}
+
+// checkStatusChange applies the workflow and the rules of subtasks and dependencies to the status change.
+// The reason of the previous change is cleared if the patch has none.
+func (u *UseCase) checkStatusChange(ctx context.Context, log *slog.Logger, current *models.Task, patch *models.TaskPatch) error {
+	reason := ""
+	if patch.StatusReason != nil {
+		reason = *patch.StatusReason
+	}
+	if err := u.workflow.check(current.Status, *patch.Status, reason); err != nil {
+		log.Error("status change is not allowed", sl.Err(err))
+		return err
+	}
+	patch.StatusReason = &reason
+
+	if current.Blocked {
+		if err := u.checkBlocked(ctx, log, current, *patch.Status); err != nil {
+			return err
+		}
+	}
+	if !u.conf.RequireSubtasksDone {
+		return nil
+	}
+	status, err := u.statuses.GetByName(ctx, *patch.Status)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("unknown status", sl.Err(err), slog.String("status", *patch.Status))
+		return service.ErrInvalidInput
+	} else if err != nil {
+		log.Error("failed to get status", sl.Err(err))
+		return service.ErrInternal
+	}
+	if status.Terminal {
+		open, err := u.repo.CountOpenDescendants(ctx, patch.ID)
+		if err != nil {
+			log.Error("failed to count open subtasks", sl.Err(err))
+			return service.ErrInternal
+		}
+		if open > 0 {
+			log.Error("task has open subtasks", slog.Int("open", open))
+			return service.ErrOpenSubtasks
+		}
+	}
+	return nil
+}
+
+// checkParent makes sure that the parent of a new task exists. A new task can't make a cycle,
+// a changed parent of an existing task is checked by the repository while the change is applied.
+// checkBlocked doesn't let a blocked task leave the first status of the board or reach a terminal status
+// until its dependencies are done.
+func (u *UseCase) checkBlocked(ctx context.Context, log *slog.Logger, current *models.Task, to string) error {
+	statuses, err := u.statuses.List(ctx)
+	if err != nil {
+		log.Error("failed to list statuses", sl.Err(err))
+		return service.ErrInternal
+	}
+	for i, status := range statuses {
+		if (i == 0 && status.Name == current.Status) || (status.Name == to && status.Terminal) {
+			log.Error("task is blocked", slog.Any("blocked_by", current.BlockedBy))
+			return service.ErrBlocked
+		}
+	}
+	return nil
+}
+
+func (u *UseCase) checkParent(ctx context.Context, log *slog.Logger, parentID int) error {
+	_, err := u.repo.Ancestors(ctx, parentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("parent task not found", sl.Err(err), slog.Int("parent_id", parentID))
+		return service.ErrParentNotFound
+	} else if err != nil {
+		log.Error("failed to get ancestors of the parent task", sl.Err(err))
+		return service.ErrInternal
+	}
+	return nil
+}
+
+// UpdateTask replaces all editable fields of the task.
+func (u *UseCase) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
 	const op = "service.tasks.UpdateTask"
 	requestID := ctx.Value(request_id.RequestIDKey).(string)
 	log := u.log.With(
 		slog.String("op", op),
 		slog.String("request_id", requestID),
 	)
-	err := u.repo.Update(ctx, task)
-	if errors.Is(repository.ErrNotFound, err) {
+	ctx = service.WithEventSource(ctx)
+	priority := task.Priority
+	if priority == 0 {
+		priority = models.PriorityMedium
+	}
+	res, err := u.update(ctx, log, &models.TaskPatch{
+		ID:          task.ID,
+		Version:     task.Version,
+		Title:       &task.Title,
+		Description: &task.Description,
+		Status:      &task.Status,
+		Priority:    &priority,
+		ProjectID:   models.NullableOf(task.ProjectID),
+		ParentID:    models.NullableOf(task.ParentID),
+		DueAt:       models.NullableOf(task.DueAt),
+		ReminderAt:  models.NullableOf(task.ReminderAt),
+		Recurrence:  &task.Recurrence,
+		Tags:        uniqueTags(task.Tags),
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("task updated", slog.Any("id", task.ID))
+	return res, nil
+}
+
+// TransitionTask changes only the status of the task.
+func (u *UseCase) TransitionTask(ctx context.Context, id int, status string, reason string, version int) (*models.Task, error) {
+	const op = "service.tasks.TransitionTask"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	res, err := u.update(ctx, log, &models.TaskPatch{
+		ID:           id,
+		Version:      version,
+		Status:       &status,
+		StatusReason: &reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("task status changed", slog.Any("id", id), slog.String("status", status))
+	return res, nil
+}
+
+// MoveTask changes the position of the task, the status change follows the same rules as TransitionTask.
+func (u *UseCase) MoveTask(ctx context.Context, move *models.TaskMove) (*models.Task, error) {
+	const op = "service.tasks.MoveTask"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	pinned, statusChanged := false, false
+	if move.Status != "" {
+		current, err := u.repo.GetByID(ctx, move.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Error("failed to get task", sl.Err(err))
+			return nil, service.ErrInternal
+		}
+		if current != nil && current.Status != move.Status {
+			statusChanged = true
+			patch := &models.TaskPatch{ID: move.ID, Status: &move.Status, StatusReason: &move.StatusReason}
+			if err = u.checkStatusChange(ctx, log, current, patch); err != nil {
+				return nil, err
+			}
+			if move.Version == 0 {
+				move.Version = current.Version
+				pinned = true
+			}
+		}
+	}
+	var res *models.Task
+	err := u.repo.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if res, err = u.repo.Move(ctx, move); err != nil {
+			return err
+		}
+		if statusChanged {
+			return u.spawnNext(ctx, log, res)
+		}
+		return nil
+	})
+	if errors.Is(err, service.ErrInternal) {
+		return nil, err
+	} else if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if errors.Is(err, repository.ErrVersionMismatch) && pinned {
+		log.Error("task changed while checking status", sl.Err(err))
+		return nil, service.ErrConflict
+	} else if errors.Is(err, repository.ErrVersionMismatch) {
+		log.Error("version mismatch", sl.Err(err), slog.Int("expected", move.Version))
+		return nil, service.ErrPreconditionFailed
+	} else if errors.Is(err, repository.ErrConcurrentUpdate) {
+		log.Error("concurrent update", sl.Err(err))
+		return nil, service.ErrConflict
+	} else if errors.Is(err, repository.ErrInvalidInput) {
+		log.Error("invalid neighbours", sl.Err(err))
+		return nil, service.ErrInvalidPosition
+	} else if err != nil {
+		log.Error("failed to move task", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("task moved", slog.Int("id", move.ID), slog.String("status", res.Status), slog.Int64("position", res.Position))
+	return res, nil
+}
+
+// PatchTask changes only the fields supplied in the patch.
+func (u *UseCase) PatchTask(ctx context.Context, patch *models.TaskPatch) (*models.Task, error) {
+	const op = "service.tasks.PatchTask"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	if patch.Tags != nil {
+		patch.Tags = uniqueTags(patch.Tags)
+	}
+	res, err := u.update(ctx, log, patch)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("task patched", slog.Any("id", patch.ID))
+	return res, nil
+}
+
+// uniqueTags normalizes tag names and removes duplicates. The result is never nil.
+func uniqueTags(tags []string) []string {
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = models.NormalizeTagName(tag)
+		if tag != "" && !slices.Contains(res, tag) {
+			res = append(res, tag)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+func (u *UseCase) update(ctx context.Context, log *slog.Logger, patch *models.TaskPatch) (*models.Task, error) {
+	var current *models.Task
+	if patch.Status != nil || (patch.Recurrence != nil && *patch.Recurrence != "") {
+		var err error
+		current, err = u.repo.GetByID(ctx, patch.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Error("failed to get task", sl.Err(err))
+			return nil, service.ErrInternal
+		}
+	}
+	if current != nil && patch.Recurrence != nil && *patch.Recurrence != "" {
+		dueAt := current.DueAt
+		if patch.DueAt.Set {
+			dueAt = patch.DueAt.Value
+		}
+		rule, err := normalizeRecurrence(*patch.Recurrence, recurrenceStart(dueAt))
+		if err != nil {
+			log.Error("invalid recurrence rule", sl.Err(err), slog.String("recurrence", *patch.Recurrence))
+			return nil, service.ErrInvalidRecurrence
+		}
+		patch.Recurrence = &rule
+	}
+	// the version is pinned so that the status change is checked against the state it is applied to
+	pinned := false
+	statusChanged := current != nil && patch.Status != nil && current.Status != *patch.Status
+	if statusChanged {
+		if err := u.checkStatusChange(ctx, log, current, patch); err != nil {
+			return nil, err
+		}
+		if patch.Version == 0 {
+			patch.Version = current.Version
+			pinned = true
+		}
+	}
+	// the next occurrence of a recurring task is created in the transaction of the status change
+	var res *models.Task
+	err := u.repo.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if res, err = u.repo.Update(ctx, patch); err != nil {
+			return err
+		}
+		if statusChanged {
+			return u.spawnNext(ctx, log, res)
+		}
+		return nil
+	})
+	if errors.Is(err, service.ErrInternal) {
+		return nil, err
+	} else if errors.Is(err, repository.ErrNotFound) {
 		log.Error("Task not found", sl.Err(err))
-		return service.ErrNotFound
-	} else if errors.Is(repository.ErrConcurrentUpdate, err) {
+		return nil, service.ErrNotFound
+	} else if errors.Is(err, repository.ErrVersionMismatch) && pinned {
+		log.Error("task changed while checking status", sl.Err(err))
+		return nil, service.ErrConflict
+	} else if errors.Is(err, repository.ErrVersionMismatch) {
+		log.Error("version mismatch", sl.Err(err), slog.Int("expected", patch.Version))
+		return nil, service.ErrPreconditionFailed
+	} else if errors.Is(err, repository.ErrConcurrentUpdate) {
 		log.Error("concurrent update", sl.Err(err))
-		return service.ErrConflict
-	} else if errors.Is(repository.ErrInvalidInput, err) {
+		return nil, service.ErrConflict
+	} else if errors.Is(err, repository.ErrParentNotFound) {
+		log.Error("parent task not found", sl.Err(err))
+		return nil, service.ErrParentNotFound
+	} else if errors.Is(err, repository.ErrCycle) {
+		log.Error("parent task makes a cycle", sl.Err(err))
+		return nil, service.ErrInvalidParent
+	} else if errors.Is(err, repository.ErrRelatedNotFound) {
+		log.Error("project not found", sl.Err(err))
+		return nil, service.ErrRelatedNotFound
+	} else if errors.Is(err, repository.ErrInvalidInput) {
 		log.Error("invalid input", sl.Err(err))
-		return service.ErrInternal
+		return nil, service.ErrInvalidInput
 	} else if err != nil {
 		log.Error("failed to update task", sl.Err(err))
-		return service.ErrInternal
+		return nil, service.ErrInternal
 	}
-	log.Info("task updated", slog.Any("id", task.ID))
-	return nil
+	return res, nil
 }
 
-func (u *UseCase) DeleteTask(ctx context.Context, id int) (*models.Task, error) {
+func (u *UseCase) DeleteTask(ctx context.Context, id int, version int) (*models.Task, error) {
 	const op = "service.tasks.DeleteTask"
 	requestID := ctx.Value(request_id.RequestIDKey).(string)
 	log := u.log.With(
 		slog.String("op", op),
 		slog.String("request_id", requestID),
 	)
-	res, err := u.repo.Delete(ctx, id)
+	ctx = service.WithEventSource(ctx)
+	res, err := u.repo.Delete(ctx, id, version)
 	if errors.Is(repository.ErrNotFound, err) {
 		log.Error("task not found", sl.Err(err))
 		return nil, service.ErrNotFound
+	} else if errors.Is(err, repository.ErrVersionMismatch) {
+		log.Error("version mismatch", sl.Err(err), slog.Int("expected", version))
+		return nil, service.ErrPreconditionFailed
 	} else if err != nil {
 		log.Error("failed to delete task", sl.Err(err))
 		return nil, service.ErrInternal
@@ -109,3 +606,123 @@ func (u *UseCase) DeleteTask(ctx context.Context, id int) (*models.Task, error)
 	log.Info("task deleted", slog.Any("id", id))
 	return res, nil
 }
+
+func (u *UseCase) RestoreTask(ctx context.Context, id int) (*models.Task, error) {
+	const op = "service.tasks.RestoreTask"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	res, err := u.repo.Restore(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found in trash", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to restore task", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("task restored", slog.Any("id", id))
+	return res, nil
+}
+
+// RevertTask applies the editable fields of the task as they were in the given version as a new update.
+// Dependencies and the position of the task are left as they are.
+func (u *UseCase) RevertTask(ctx context.Context, id int, to int, version int) (*models.Task, error) {
+	const op = "service.tasks.RevertTask"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	current, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if err != nil {
+		log.Error("failed to get task", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	snapshot, err := u.repo.Snapshot(ctx, id, to)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("task version not found", sl.Err(err), slog.Int("to", to))
+		return nil, service.ErrVersionNotFound
+	} else if err != nil {
+		log.Error("failed to get task version", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+
+	// without If-Match the snapshot is applied only to the state it has been compared with
+	pinned := version == 0
+	if pinned {
+		version = current.Version
+	}
+	res, err := u.update(ctx, log, &models.TaskPatch{
+		ID:           id,
+		Version:      version,
+		Title:        &snapshot.Title,
+		Description:  &snapshot.Description,
+		Status:       &snapshot.Status,
+		StatusReason: &snapshot.StatusReason,
+		Priority:     &snapshot.Priority,
+		ProjectID:    models.NullableOf(snapshot.ProjectID),
+		ParentID:     models.NullableOf(snapshot.ParentID),
+		DueAt:        models.NullableOf(snapshot.DueAt),
+		ReminderAt:   models.NullableOf(snapshot.ReminderAt),
+		Recurrence:   &snapshot.Recurrence,
+		Tags:         uniqueTags(snapshot.Tags),
+	})
+	if errors.Is(err, service.ErrPreconditionFailed) && pinned {
+		log.Error("task changed while reverting", sl.Err(err))
+		return nil, service.ErrConflict
+	} else if err != nil {
+		return nil, err
+	}
+	log.Info("task reverted", slog.Int("id", id), slog.Int("to", to), slog.Int("version", res.Version))
+	return res, nil
+}
+
+// UndeleteTask takes the task out of the trash or recreates it if the trash has already been purged.
+func (u *UseCase) UndeleteTask(ctx context.Context, id int, version int) (*models.Task, error) {
+	const op = "service.tasks.UndeleteTask"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	ctx = service.WithEventSource(ctx)
+	res, err := u.repo.Undelete(ctx, id, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("deleted task not found", sl.Err(err))
+		return nil, service.ErrNotFound
+	} else if errors.Is(err, repository.ErrVersionMismatch) {
+		log.Error("version mismatch", sl.Err(err), slog.Int("expected", version))
+		return nil, service.ErrPreconditionFailed
+	} else if errors.Is(err, repository.ErrInvalidInput) {
+		log.Error("status of the task no longer exists", sl.Err(err))
+		return nil, service.ErrInvalidInput
+	} else if err != nil {
+		log.Error("failed to undelete task", sl.Err(err))
+		return nil, service.ErrInternal
+	}
+	log.Info("task undeleted", slog.Int("id", id), slog.Int("version", res.Version))
+	return res, nil
+}
+
+func (u *UseCase) PurgeTrash(ctx context.Context) (int64, error) {
+	const op = "service.tasks.PurgeTrash"
+	requestID := ctx.Value(request_id.RequestIDKey).(string)
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("request_id", requestID),
+	)
+	purged, err := u.repo.Purge(ctx, time.Now().Add(-u.conf.TrashRetention))
+	if err != nil {
+		log.Error("failed to purge trash", sl.Err(err))
+		return 0, service.ErrInternal
+	}
+	log.Info("trash purged", slog.Int64("count", purged))
+	return purged, nil
+}
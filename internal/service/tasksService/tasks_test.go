@@ -0,0 +1,173 @@
+package tasksService
+
+import (
+	"context"
+	"errors"
+	"github.com/igorgrichanov/toDoList/internal/config"
+	"github.com/igorgrichanov/toDoList/internal/controller/http/middleware/request_id"
+	"github.com/igorgrichanov/toDoList/internal/models"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"log/slog"
+	"testing"
+)
+
+// boardStatuses keeps the board in memory.
+type boardStatuses []*models.Status
+
+func (b boardStatuses) GetByName(_ context.Context, name string) (*models.Status, error) {
+	for _, status := range b {
+		if status.Name == name {
+			return status, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (b boardStatuses) List(_ context.Context) ([]*models.Status, error) {
+	return b, nil
+}
+
+var board = boardStatuses{
+	{Name: models.StatusNew, Position: 1},
+	{Name: models.StatusInProgress, Position: 2},
+	{Name: "review", Position: 3},
+	{Name: models.StatusDone, Position: 4, Terminal: true},
+}
+
+func TestCheckStatusChangeBlocked(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		blocked bool
+		want    error
+	}{
+		{
+			name:    "blocked task can't leave the first status",
+			from:    models.StatusNew,
+			to:      "review",
+			blocked: true,
+			want:    service.ErrBlocked,
+		},
+		{
+			name:    "blocked task can't be finished",
+			from:    models.StatusInProgress,
+			to:      models.StatusDone,
+			blocked: true,
+			want:    service.ErrBlocked,
+		},
+		{
+			name:    "blocked task can be moved between other statuses",
+			from:    "review",
+			to:      models.StatusInProgress,
+			blocked: true,
+		},
+		{
+			name: "task without dependencies can be finished",
+			from: models.StatusNew,
+			to:   models.StatusDone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.DiscardHandler)
+			u := NewUseCase(log, nil, board, &config.Tasks{})
+			current := &models.Task{ID: 1, Status: tt.from, Blocked: tt.blocked, BlockedBy: []int{2}}
+			patch := &models.TaskPatch{ID: 1, Status: &tt.to}
+			if err := u.checkStatusChange(context.Background(), log, current, patch); !errors.Is(err, tt.want) {
+				t.Errorf("checkStatusChange() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// versionedTasks keeps one task in memory and fails the changes made against another version of it,
+// the methods the tests don't call are left to the nil TasksRepository.
+type versionedTasks struct {
+	TasksRepository
+	task *models.Task
+	// changed is the version the task gets after GetByID, as if it has been changed concurrently
+	changed int
+}
+
+func (r *versionedTasks) GetByID(_ context.Context, _ int) (*models.Task, error) {
+	task := *r.task
+	if r.changed != 0 {
+		r.task.Version = r.changed
+	}
+	return &task, nil
+}
+
+func (r *versionedTasks) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (r *versionedTasks) change(version int, status string) (*models.Task, error) {
+	if version != 0 && version != r.task.Version {
+		return nil, repository.ErrVersionMismatch
+	}
+	task := *r.task
+	task.Status = status
+	task.Version++
+	return &task, nil
+}
+
+func (r *versionedTasks) Update(_ context.Context, patch *models.TaskPatch) (*models.Task, error) {
+	return r.change(patch.Version, *patch.Status)
+}
+
+func (r *versionedTasks) Move(_ context.Context, move *models.TaskMove) (*models.Task, error) {
+	return r.change(move.Version, move.Status)
+}
+
+func TestStatusChangeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		changed int
+		want    error
+	}{
+		{
+			name: "unchanged task",
+		},
+		{
+			name:    "task changed while the status change is checked",
+			changed: 2,
+			want:    service.ErrConflict,
+		},
+		{
+			name:    "version of the request",
+			version: 1,
+		},
+		{
+			name:    "task changed after the version of the request",
+			version: 1,
+			changed: 2,
+			want:    service.ErrPreconditionFailed,
+		},
+	}
+	changes := map[string]func(u *UseCase, ctx context.Context, version int) error{
+		"PatchTask": func(u *UseCase, ctx context.Context, version int) error {
+			status := models.StatusInProgress
+			_, err := u.PatchTask(ctx, &models.TaskPatch{ID: 1, Version: version, Status: &status})
+			return err
+		},
+		"MoveTask": func(u *UseCase, ctx context.Context, version int) error {
+			_, err := u.MoveTask(ctx, &models.TaskMove{ID: 1, Version: version, Status: models.StatusInProgress})
+			return err
+		},
+	}
+	for method, change := range changes {
+		for _, tt := range tests {
+			t.Run(method+"/"+tt.name, func(t *testing.T) {
+				repo := &versionedTasks{task: &models.Task{ID: 1, Status: models.StatusNew, Version: 1}, changed: tt.changed}
+				u := NewUseCase(slog.New(slog.DiscardHandler), repo, board, &config.Tasks{})
+				ctx := context.WithValue(context.Background(), request_id.RequestIDKey, "test")
+				if err := change(u, ctx, tt.version); !errors.Is(err, tt.want) {
+					t.Errorf("%s() error = %v, want %v", method, err, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tasksService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/igorgrichanov/toDoList/internal/config"
+	"github.com/igorgrichanov/toDoList/internal/repository"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"slices"
+)
+
+type transition struct {
+	from string
+	to   string
+}
+
+// workflow maps allowed status changes to whether they require a reason.
+type workflow map[transition]bool
+
+func newWorkflow(transitions []config.Transition) workflow {
+	w := make(workflow, len(transitions))
+	for _, t := range transitions {
+		w[transition{from: t.From, to: t.To}] = t.RequiresReason
+	}
+	return w
+}
+
+// statuses returns the names of the statuses the workflow mentions.
+func (w workflow) statuses() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for t := range w {
+		for _, name := range []string{t.from, t.to} {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// check returns *service.TransitionError if the status can't be changed. An empty workflow allows any change,
+// changes to and from the statuses the workflow doesn't mention, such as the ones created later, are allowed too.
+func (w workflow) check(from, to, reason string) error {
+	if len(w) == 0 || from == to {
+		return nil
+	}
+	if names := w.statuses(); !slices.Contains(names, from) || !slices.Contains(names, to) {
+		return nil
+	}
+	requiresReason, ok := w[transition{from: from, to: to}]
+	if !ok {
+		return &service.TransitionError{From: from, To: to}
+	}
+	if requiresReason && reason == "" {
+		return &service.TransitionError{From: from, To: to, ReasonRequired: true}
+	}
+	return nil
+}
+
+// CheckWorkflow returns an error if the configured workflow mentions a status that doesn't exist,
+// it is called once at startup.
+func (u *UseCase) CheckWorkflow(ctx context.Context) error {
+	for _, name := range u.workflow.statuses() {
+		_, err := u.statuses.GetByName(ctx, name)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("unknown status '%s'", name)
+		} else if err != nil {
+			return fmt.Errorf("failed to get status '%s': %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package tasksService
+
+import (
+	"github.com/igorgrichanov/toDoList/internal/config"
+	"github.com/igorgrichanov/toDoList/internal/service"
+	"reflect"
+	"testing"
+)
+
+func TestWorkflowCheck(t *testing.T) {
+	w := newWorkflow([]config.Transition{
+		{From: "new", To: "in_progress"},
+		{From: "in_progress", To: "done"},
+		{From: "done", To: "in_progress", RequiresReason: true},
+	})
+	tests := []struct {
+		name     string
+		workflow workflow
+		from     string
+		to       string
+		reason   string
+		want     error
+	}{
+		{
+			name:     "empty workflow allows any change",
+			workflow: newWorkflow(nil),
+			from:     "new",
+			to:       "done",
+		},
+		{
+			name:     "status isn't changed",
+			workflow: w,
+			from:     "new",
+			to:       "new",
+		},
+		{
+			name:     "allowed change",
+			workflow: w,
+			from:     "new",
+			to:       "in_progress",
+		},
+		{
+			name:     "change not in the workflow",
+			workflow: w,
+			from:     "new",
+			to:       "done",
+			want:     &service.TransitionError{From: "new", To: "done"},
+		},
+		{
+			name:     "change without the required reason",
+			workflow: w,
+			from:     "done",
+			to:       "in_progress",
+			want:     &service.TransitionError{From: "done", To: "in_progress", ReasonRequired: true},
+		},
+		{
+			name:     "change with the required reason",
+			workflow: w,
+			from:     "done",
+			to:       "in_progress",
+			reason:   "not finished",
+		},
+		{
+			name:     "status the workflow doesn't mention",
+			workflow: w,
+			from:     "new",
+			to:       "blocked",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.workflow.check(tt.from, tt.to, tt.reason); !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("check() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowStatuses(t *testing.T) {
+	w := newWorkflow([]config.Transition{
+		{From: "new", To: "in_progress"},
+		{From: "in_progress", To: "done"},
+	})
+	want := []string{"done", "in_progress", "new"}
+	if got := w.statuses(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses() = %v, want %v", got, want)
+	}
+}
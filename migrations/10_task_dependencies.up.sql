@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS task_dependencies
+(
+    task_id INTEGER NOT NULL
+        constraint fk_task_dependencies_task
+            references tasks (id) ON DELETE CASCADE,
+    blocked_by_id INTEGER NOT NULL
+        constraint fk_task_dependencies_blocked_by
+            references tasks (id) ON DELETE CASCADE,
+    constraint pk_task_dependencies
+        primary key (task_id, blocked_by_id),
+    constraint chk_task_dependencies_self
+        check (task_id <> blocked_by_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked_by_id ON task_dependencies (blocked_by_id);
\ No newline at end of file
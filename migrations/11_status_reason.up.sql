@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS status_reason TEXT NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS fk_tasks_status;
+
+UPDATE tasks SET status = 'new' WHERE status NOT IN ('new', 'in_progress', 'done');
+
+ALTER TABLE tasks
+    ALTER COLUMN status DROP NOT NULL,
+    ALTER COLUMN status SET DEFAULT 'new',
+    ADD CONSTRAINT tasks_status_check CHECK (status IN ('new', 'in_progress', 'done'));
+
+drop table if exists statuses;
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS statuses
+(
+    name TEXT not null
+        constraint pk_statuses
+            primary key,
+    position INTEGER NOT NULL,
+    terminal BOOLEAN NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+INSERT INTO statuses (name, position, terminal)
+VALUES ('new', 1, false),
+       ('in_progress', 2, false),
+       ('done', 3, true)
+ON CONFLICT DO NOTHING;
+
+UPDATE tasks SET status = 'new' WHERE status IS NULL;
+
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS tasks_status_check,
+    ALTER COLUMN status DROP DEFAULT,
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT fk_tasks_status
+        FOREIGN KEY (status) REFERENCES statuses (name) ON UPDATE CASCADE;
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS position BIGINT NOT NULL DEFAULT 0;
+
+UPDATE tasks
+SET position = ordered.rn * 65536
+FROM (SELECT id, row_number() OVER (PARTITION BY status ORDER BY id) AS rn FROM tasks) AS ordered
+WHERE tasks.id = ordered.id;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_status_position ON tasks (status, position);
\ No newline at end of file
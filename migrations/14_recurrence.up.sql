@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS recurrence TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS series_id INT;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_series_id ON tasks (series_id);
\ No newline at end of file
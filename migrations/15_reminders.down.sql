@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tasks_pending_reminders;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS reminder_sent_at,
+    DROP COLUMN IF EXISTS reminder_at;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS reminder_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS reminder_sent_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_pending_reminders ON tasks (reminder_at)
+    WHERE reminder_sent_at IS NULL AND deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS comments
+(
+    id SERIAL not null
+        constraint pk_comments
+            primary key,
+    task_id INTEGER NOT NULL
+        constraint fk_comments_task
+            references tasks (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_task_id ON comments (task_id, id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS task_events;
\ No newline at end of file
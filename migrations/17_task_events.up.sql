@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS task_events
+(
+    id BIGSERIAL not null
+        constraint pk_task_events
+            primary key,
+    task_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    request_id TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL
+);
+
+-- events have no foreign key to tasks, so that the history outlives purged tasks
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events (task_id, id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS task_versions;
\ No newline at end of file
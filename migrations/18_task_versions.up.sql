@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS task_versions
+(
+    task_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    created_at timestamptz NOT NULL,
+    constraint pk_task_versions
+        primary key (task_id, version)
+);
+
+-- snapshots have no foreign key to tasks, so that purged tasks can be undeleted
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS reminder_claimed_until TIMESTAMPTZ;
\ No newline at end of file
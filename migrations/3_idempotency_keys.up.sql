@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    key TEXT NOT NULL
+        constraint pk_idempotency_keys
+            primary key,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER,
+    response_body BYTEA,
+    created_at timestamptz NOT NULL
+);
\ No newline at end of file
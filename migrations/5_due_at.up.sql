@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS due_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_due_at ON tasks (due_at)
+    WHERE deleted_at IS NULL;
\ No newline at end of file
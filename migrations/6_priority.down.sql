@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_priority;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS priority;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 2
+        CHECK (priority BETWEEN 1 AND 4);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks (priority)
+    WHERE deleted_at IS NULL;
\ No newline at end of file
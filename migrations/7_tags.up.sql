@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS tags
+(
+    id SERIAL not null
+        constraint pk_tags
+            primary key,
+    name TEXT NOT NULL
+        constraint uq_tags_name
+            unique,
+    created_at timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_tags
+(
+    task_id INTEGER NOT NULL
+        constraint fk_task_tags_task
+            references tasks (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL
+        constraint fk_task_tags_tag
+            references tags (id) ON DELETE CASCADE,
+    constraint pk_task_tags
+        primary key (task_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag_id ON task_tags (tag_id);
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_project_id;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS project_id;
+
+drop table if exists projects;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS projects
+(
+    id SERIAL not null
+        constraint pk_projects
+            primary key,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS project_id INTEGER
+        constraint fk_tasks_project
+            references projects (id);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks (project_id);
\ No newline at end of file
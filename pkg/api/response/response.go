@@ -24,6 +24,16 @@ func ValidationError(errs validator.ValidationErrors) string {
 			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' is required", field))
 		case "oneof":
 			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' must be one of [%s]", field, err.Param()))
+		case "min":
+			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' must be at least %s", field, err.Param()))
+		case "max":
+			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' must be at most %s", field, err.Param()))
+		case "datetime":
+			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' must be a timestamp in RFC 3339 format", field))
+		case "status":
+			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' must be one of the existing statuses", field))
+		case "excluded_with":
+			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' can't be used together with '%s'", field, err.Param()))
 		default:
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", field))
 		}
@@ -32,6 +42,15 @@ func ValidationError(errs validator.ValidationErrors) string {
 	return strings.Join(errMsgs, ", ")
 }
 
+// Error responds with the status code and the message.
+func Error(c *fiber.Ctx, status int, err string) error {
+	resp := Response{
+		Success: false,
+		Message: err,
+	}
+	return c.Status(status).JSON(resp)
+}
+
 func ErrorBadRequest(c *fiber.Ctx, err string) error {
 	resp := Response{
 		Success: false,
@@ -63,3 +82,19 @@ func ErrorConflict(c *fiber.Ctx, err string) error {
 	}
 	return c.Status(http.StatusConflict).JSON(resp)
 }
+
+func ErrorPreconditionFailed(c *fiber.Ctx) error {
+	resp := Response{
+		Success: false,
+		Message: http.StatusText(http.StatusPreconditionFailed),
+	}
+	return c.Status(http.StatusPreconditionFailed).JSON(resp)
+}
+
+func ErrorUnprocessableEntity(c *fiber.Ctx, err string) error {
+	resp := Response{
+		Success: false,
+		Message: err,
+	}
+	return c.Status(http.StatusUnprocessableEntity).JSON(resp)
+}
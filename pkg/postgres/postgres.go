@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/igorgrichanov/toDoList/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log/slog"
 	"time"
@@ -55,3 +57,44 @@ func (p *Postgres) Close() {
 		p.Pool.Close()
 	}
 }
+
+// Querier is implemented by both the pool and transactions.
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Key to store the transaction in a context. Non-exported type is used to avoid collisions
+type ctxKeyTx struct{}
+
+// Conn returns the transaction started by WithTx for the context or the pool if there is none.
+func (p *Postgres) Conn(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(ctxKeyTx{}).(pgx.Tx); ok {
+		return tx
+	}
+	return p.Pool
+}
+
+// WithTx runs fn in a transaction, which is committed if fn returns nil and rolled back otherwise.
+// Queries made through Conn with the context passed to fn use the transaction.
+// Nested calls run in a savepoint of the outer transaction.
+func (p *Postgres) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := p.Conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		// no-op if the transaction has been committed
+		_ = tx.Rollback(ctx)
+	}()
+	if err = fn(context.WithValue(ctx, ctxKeyTx{}, tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}